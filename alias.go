@@ -0,0 +1,79 @@
+package konfig
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	keyAliasesMu sync.RWMutex
+	keyAliases   = map[string]string{}
+)
+
+// RegisterKeyAlias declares oldKey a deprecated name for newKey
+//
+// When a loaded file contains oldKey, its value is copied to newKey unless
+// newKey is set as well, and a deprecation warning is logged through the
+// logger from WithLogger. Keys below oldKey are mapped too, so aliasing "db"
+// to "database" carries "db.host" over to "database.host". The old key is
+// kept, so code still reading it keeps working during the migration.
+//
+// Example:
+//
+//	konfig.RegisterKeyAlias("db.dsn", "database.url")
+func RegisterKeyAlias(oldKey, newKey string) {
+	keyAliasesMu.Lock()
+	defer keyAliasesMu.Unlock()
+
+	if newKey == "" {
+		delete(keyAliases, oldKey)
+		return
+	}
+	keyAliases[oldKey] = newKey
+}
+
+// applyKeyAliases copies values of deprecated keys in data, whose keys are
+// joined by sep, to their replacements; origin names the file in warnings
+func applyKeyAliases(data map[string]interface{}, origin, sep string, logger *slog.Logger) {
+	keyAliasesMu.RLock()
+	defer keyAliasesMu.RUnlock()
+
+	if len(keyAliases) == 0 {
+		return
+	}
+
+	oldKeys := make([]string, 0, len(keyAliases))
+	for oldKey := range keyAliases {
+		oldKeys = append(oldKeys, oldKey)
+	}
+	sort.Strings(oldKeys)
+
+	for _, oldKey := range oldKeys {
+		newKey := keyAliases[oldKey]
+
+		// Collect first: data must not grow while it is being ranged over
+		copies := make(map[string]interface{})
+		for key, value := range data {
+			if key == oldKey {
+				copies[newKey] = value
+			} else if rest, found := strings.CutPrefix(key, oldKey+sep); found {
+				copies[newKey+sep+rest] = value
+			}
+		}
+
+		for target, value := range copies {
+			if _, exists := data[target]; !exists {
+				data[target] = value
+			}
+		}
+
+		if len(copies) > 0 {
+			logger.Warn("deprecated configuration key",
+				"key", oldKey,
+				"replacement", newKey,
+				"source", origin)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // BenchmarkLoad_SmallConfig benchmarks loading a typical small configuration
@@ -279,6 +281,62 @@ health:
 	}
 }
 
+// BenchmarkUnmarshal_RepeatedType benchmarks populating the same struct type
+// over and over, as BindStruct does on every Reload, without file I/O
+func BenchmarkUnmarshal_RepeatedType(b *testing.B) {
+	cfg := FromMap(map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":    "localhost",
+			"port":    8080,
+			"timeout": "30s",
+		},
+		"database": map[string]interface{}{
+			"host":      "localhost",
+			"port":      5432,
+			"name":      "myapp",
+			"pool_size": 10,
+		},
+		"cache": map[string]interface{}{
+			"enabled": true,
+			"ttl":     "5m",
+		},
+	})
+
+	type ServerConfig struct {
+		Host    string        `konfig:"host" default:"0.0.0.0"`
+		Port    int           `konfig:"port" default:"8080"`
+		Timeout time.Duration `konfig:"timeout" default:"10s"`
+	}
+
+	type DatabaseConfig struct {
+		Host     string `konfig:"host" transform:"trim,lower"`
+		Port     int    `konfig:"port"`
+		Name     string `konfig:"name"`
+		PoolSize int    `konfig:"pool_size" default:"5"`
+		User     string `konfig:"user" env:"BENCH_DB_USER" default:"app"`
+	}
+
+	type CacheConfig struct {
+		Enabled bool          `konfig:"enabled"`
+		TTL     time.Duration `konfig:"ttl"`
+	}
+
+	type Config struct {
+		Server   ServerConfig   `konfig:"server"`
+		Database DatabaseConfig `konfig:"database"`
+		Cache    CacheConfig    `konfig:"cache"`
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var target Config
+		if err := cfg.Unmarshal(&target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // BenchmarkConfigAccess benchmarks accessing configuration values
 func BenchmarkConfigAccess(b *testing.B) {
 	tempDir := b.TempDir()
@@ -323,6 +381,64 @@ database:
 	})
 }
 
+// rwMutexStore mirrors the previous RWMutex-guarded config store for comparison
+type rwMutexStore struct {
+	data map[string]interface{}
+	mu   sync.RWMutex
+}
+
+func (s *rwMutexStore) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.data[key]
+	return value, exists
+}
+
+func (s *rwMutexStore) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+}
+
+// BenchmarkConfigAccess_ReadContention compares parallel reads with an
+// occasional writer for the RWMutex store and the copy-on-write store
+func BenchmarkConfigAccess_ReadContention(b *testing.B) {
+	data := map[string]interface{}{
+		"server.port":  8080,
+		"server.host":  "localhost",
+		"database.url": "postgres://localhost/test",
+	}
+
+	run := func(b *testing.B, get func(string) (interface{}, bool), set func(string, interface{})) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if i%1000 == 0 {
+					set("server.port", i)
+				}
+				_, _ = get("server.host")
+				i++
+			}
+		})
+	}
+
+	b.Run("RWMutex", func(b *testing.B) {
+		store := &rwMutexStore{data: make(map[string]interface{}, len(data))}
+		for k, v := range data {
+			store.data[k] = v
+		}
+		run(b, store.Get, store.Set)
+	})
+
+	b.Run("CopyOnWrite", func(b *testing.B) {
+		cfg := newConfig(data)
+		run(b, cfg.Get, cfg.Set)
+	})
+}
+
 // BenchmarkEnvSubstitution benchmarks environment variable substitution
 func BenchmarkEnvSubstitution(b *testing.B) {
 	// Set test environment variables
@@ -0,0 +1,98 @@
+package konfig
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	defaultFuncsMu sync.RWMutex
+	defaultFuncs   = map[string]func() string{
+		"hostname": defaultHostname,
+		"uuid":     defaultUUID,
+	}
+)
+
+// RegisterDefaultFunc makes fn available to the defaultFunc struct tag
+//
+// The function is called when a field's key is absent and the field has no
+// static default tag. Registering an existing name replaces it and a nil fn
+// removes it; "hostname" and "uuid" are built in. It is safe to call
+// concurrently with loading.
+//
+// Example:
+//
+//	konfig.RegisterDefaultFunc("pid", func() string { return strconv.Itoa(os.Getpid()) })
+//
+//	type Config struct {
+//	    Instance string `konfig:"app.instance" defaultFunc:"hostname"`
+//	}
+func RegisterDefaultFunc(name string, fn func() string) {
+	defaultFuncsMu.Lock()
+	defer defaultFuncsMu.Unlock()
+
+	if fn == nil {
+		delete(defaultFuncs, name)
+		return
+	}
+	defaultFuncs[name] = fn
+}
+
+// fileRefRegex matches ${file:PATH} references in default tags
+var fileRefRegex = regexp.MustCompile(`\$\{file:([^}]+)\}`)
+
+// expandFileRefs replaces every ${file:PATH} in a default tag value with the
+// contents of PATH minus trailing newlines, as with Docker and Kubernetes
+// secret files. Unreadable files expand to "" and the first read error is
+// returned alongside the result.
+func expandFileRefs(value string) (string, error) {
+	var firstErr error
+	expanded := fileRefRegex.ReplaceAllStringFunc(value, func(match string) string {
+		path := fileRefRegex.FindStringSubmatch(match)[1]
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("cannot read secret file %s: %w", path, err)
+			}
+			return ""
+		}
+		return strings.TrimRight(string(data), "\r\n")
+	})
+	return expanded, firstErr
+}
+
+func lookupDefaultFunc(name string) (func() string, bool) {
+	if name == "" {
+		return nil, false
+	}
+
+	defaultFuncsMu.RLock()
+	defer defaultFuncsMu.RUnlock()
+
+	fn, ok := defaultFuncs[name]
+	return fn, ok
+}
+
+// defaultHostname returns the host name, or "" if it cannot be determined
+func defaultHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+// defaultUUID returns a random (version 4) UUID
+func defaultUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,68 @@
+package konfig
+
+import (
+	"os"
+	"strings"
+)
+
+// LoadFromEnv builds a configuration from environment variables sharing a prefix
+//
+// The prefix is stripped and the remainder lowercased to form the key. When the
+// remainder contains a double underscore, "__" separates key segments and single
+// underscores are kept (APP_DB__POOL_SIZE → db.pool_size); otherwise every "_"
+// separates segments (APP_SERVER_PORT → server.port). Values are stored as
+// strings and read through the usual getters.
+//
+// Example:
+//
+//	// APP_SERVER__PORT=8080
+//	cfg, err := konfig.LoadFromEnv("APP_")
+//	port := cfg.GetInt("server.port") // 8080
+func LoadFromEnv(prefix string) (Config, error) {
+	if prefix == "" {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    "env",
+			Message: "environment prefix cannot be empty",
+		}
+	}
+
+	cfg := loadFromEnv(prefix)
+	cfg.source = func() (*config, error) { return loadFromEnv(prefix), nil }
+
+	return cfg, nil
+}
+
+func loadFromEnv(prefix string) *config {
+	data := make(map[string]interface{})
+	for _, entry := range os.Environ() {
+		name, value, found := strings.Cut(entry, "=")
+		if !found || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		if key := envNameToKey(strings.TrimPrefix(name, prefix)); key != "" {
+			data[key] = value
+		}
+	}
+
+	return newConfig(data)
+}
+
+// envNameToKey converts the unprefixed part of an env var name to a dotted key
+func envNameToKey(name string) string {
+	name = strings.ToLower(strings.Trim(name, "_"))
+
+	separator := "_"
+	if strings.Contains(name, "__") {
+		separator = "__"
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(name, separator) {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, ".")
+}
@@ -0,0 +1,37 @@
+package konfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("KTEST_SERVER__PORT", "8080")
+	t.Setenv("KTEST_DB__POOL_SIZE", "10")
+	t.Setenv("KTEST_LOG_LEVEL", "debug")
+	t.Setenv("OTHER_SERVER__PORT", "9999")
+
+	cfg, err := LoadFromEnv("KTEST_")
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	assert.Equal(t, 10, cfg.GetInt("db.pool_size"))
+	assert.Equal(t, "debug", cfg.GetString("log.level"))
+	assert.ElementsMatch(t, []string{"server.port", "db.pool_size", "log.level"}, cfg.Keys())
+
+	// Prefix without trailing underscore behaves the same
+	cfg, err = LoadFromEnv("KTEST")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+
+	// Reload picks up environment changes
+	t.Setenv("KTEST_SERVER__PORT", "9090")
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 9090, cfg.GetInt("server.port"))
+
+	_, err = LoadFromEnv("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+}
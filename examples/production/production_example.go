@@ -47,8 +47,11 @@ type CacheConfig struct {
 }
 
 type LoggingConfig struct {
-	Level  string `konfig:"level" default:"info"`
-	Format string `konfig:"format" default:"json"`
+	Level      string        `konfig:"level" default:"info"`
+	Format     string        `konfig:"format" default:"json"`
+	MaxSize    int64         `konfig:"max_size" format:"bytes" default:"100MiB"`
+	MaxBackups int           `konfig:"max_backups" format:"count" default:"3"`
+	MaxAge     time.Duration `konfig:"max_age" default:"28d"`
 }
 
 type MetricsConfig struct {
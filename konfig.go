@@ -25,14 +25,25 @@
 package konfig
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
 )
 
 // Config provides type-safe access to configuration values
@@ -42,24 +53,242 @@ type Config interface {
 
 	// Type-safe getters with sensible defaults
 	GetString(key string) string
+	// GetInt parses integers and truncates float values toward zero
+	// ("30.9" -> 30); returns 0 if missing, invalid or out of int range. It
+	// is the value of Int with present and err ignored.
 	GetInt(key string) int
+
+	// Int is the authoritative integer getter: present is false when key is
+	// missing, null or empty, err is a type_error when the value is not an
+	// integer or out of int range (floats are truncated as for GetInt), and
+	// value is valid otherwise
+	Int(key string) (value int, present bool, err error)
+
+	// GetInt64 is like GetInt but always 64 bits wide, for byte counts and
+	// IDs that may not fit an int on 32-bit platforms
+	GetInt64(key string) int64
+
 	GetBool(key string) bool
+
+	// GetBoolE returns a type_error for values that are not booleans; besides
+	// strconv.ParseBool syntax it accepts yes/no, y/n and on/off in any case.
+	// Numeric YAML values (enabled: 2, 1.0) are true unless zero, while
+	// strings such as "2" are still rejected. A missing key yields false and
+	// no error.
+	GetBoolE(key string) (bool, error)
 	GetFloat64(key string) float64
+
+	// GetFloat64E returns a type_error for values that are not dot-decimal
+	// numbers. Commas are never accepted, neither as decimal nor as thousands
+	// separators; "3,14" is reported with a hint to write "3.14". A missing key
+	// yields 0 and no error.
+	GetFloat64E(key string) (float64, error)
+
+	// GetTime returns YAML timestamps as decoded and parses RFC 3339 or
+	// YYYY-MM-DD strings, returning the zero time if missing or invalid
+	GetTime(key string) time.Time
+
+	// GetTimeWithLayout parses the value with a time.Parse layout such as
+	// "02/01/2006", returning a type_error naming the layout and value when it
+	// does not match; native YAML timestamps are returned as is
+	GetTimeWithLayout(key, layout string) (time.Time, error)
+
+	// GetDuration accepts time.ParseDuration syntax plus d (24h) and w (7d)
+	// units; stored time.Duration values are returned as is and plain numbers
+	// are read as seconds
 	GetDuration(key string) time.Duration
 
+	// GetDurationClamped is GetDuration bounded to [min, max], so that a
+	// misconfigured 0s or 999h timeout stays sane. A clamped value and an
+	// unparseable one, which yields def, are logged as warnings (see
+	// WithLogger); a missing key yields def silently.
+	GetDurationClamped(key string, min, max, def time.Duration) time.Duration
+
+	// GetMany returns the GetString value of each key, read from a single
+	// snapshot so that related keys (e.g. the parts of a DSN) are consistent;
+	// missing keys map to ""
+	GetMany(keys ...string) map[string]string
+
+	// GetManyE is like GetMany but returns a validation_error naming every
+	// missing key
+	GetManyE(keys ...string) (map[string]string, error)
+
 	// GetStringWithDefault returns the value or default if not found
 	GetStringWithDefault(key, defaultValue string) string
+
+	// GetStringWithDefaultTrimmed is like GetStringWithDefault but also returns
+	// the default for whitespace-only values; non-blank values are returned as is
+	GetStringWithDefaultTrimmed(key, defaultValue string) string
+
+	// GetStringOrEnv returns the config value if non-empty, otherwise the
+	// value of the process environment variable envVar, otherwise "". Unlike
+	// ${VAR} substitution it is evaluated on every call, which eases migrating
+	// env-based lookups to config files one key at a time.
+	GetStringOrEnv(key, envVar string) string
+
 	GetIntWithDefault(key string, defaultValue int) int
 	GetBoolWithDefault(key string, defaultValue bool) bool
 
+	// GetPercent parses "10%" as the fraction 0.1; values without % are
+	// returned as plain floats. Returns 0 if missing or invalid.
+	GetPercent(key string) float64
+
+	// GetCount parses counts with SI suffixes (k = 1000, m = 1e6, g = 1e9),
+	// e.g. "10k", returning 0 if missing or invalid
+	GetCount(key string) int64
+
+	// GetStringSlice returns a list value as strings. When indexed keys such
+	// as "ports.1" coexist with a whole list (e.g. a profile overriding one
+	// element), the indexed keys win at their positions and the whole list
+	// supplies the rest; indexes past its end extend the slice, leaving gaps
+	// empty. Indexed keys alone form the slice. A scalar value is split on
+	// commas and newlines, trimming items and dropping empty ones
+	// ("a, b ,,c" gives [a b c]); with WithKeepEmptySliceItems it is split on
+	// commas verbatim. Returns nil if key is missing.
+	GetStringSlice(key string) []string
+
+	// GetIntSlice is like GetStringSlice but converts each element to an int,
+	// returning nil if any element is not an integer
+	GetIntSlice(key string) []int
+
+	// GetDurationSlice parses each list element, or each item of a
+	// comma-separated value, like GetDuration; returns nil if any is invalid
+	GetDurationSlice(key string) []time.Duration
+
+	// GetStringMap returns all values below key with the key prefix removed;
+	// deeper descendants keep their remaining dotted path
+	GetStringMap(key string) map[string]string
+
+	// GetStringMapDepth is like GetStringMap but limited to depth levels
+	// below key: with depth 1 only immediate children are returned. Deeper
+	// descendants are dropped, or with collapse each subtree cut at the limit
+	// is returned as one JSON object under its path. A depth below 1 means
+	// no limit.
+	GetStringMapDepth(key string, depth int, collapse bool) map[string]string
+
+	// GetStringMapInterface returns the immediate children of key with their
+	// native values; nested subtrees become map[string]interface{} values
+	GetStringMapInterface(key string) map[string]interface{}
+
+	// GetStringMapE is like GetStringMap but returns a type_error unless every
+	// child is a scalar directly below key
+	GetStringMapE(key string) (map[string]string, error)
+
+	// GetAllWithPrefix returns the raw values below prefix with the prefix
+	// removed, or an empty map when nothing matches
+	GetAllWithPrefix(prefix string) map[string]interface{}
+
+	// GetSubConfigs returns one Config per immediate child namespace of
+	// prefix, with keys relative to that child; children holding only a
+	// scalar are skipped. Returns an empty map when nothing matches.
+	GetSubConfigs(prefix string) map[string]Config
+
+	// HasPrefix reports whether any key lies below prefix, i.e. whether a
+	// section such as "database" exists, before calling Sub or Unmarshal
+	HasPrefix(prefix string) bool
+
+	// Sub returns a Config holding the values below prefix with the prefix
+	// removed, so a component can Unmarshal its own section with tags
+	// relative to it. The result is a detached snapshot: later Set and
+	// Reload calls on the parent do not affect it. An empty prefix copies
+	// everything.
+	Sub(prefix string) Config
+
+	// GetBytesBase64 decodes a standard base64 value, returning nil if missing or invalid
+	GetBytesBase64(key string) []byte
+
+	// GetBytesHex decodes a hex value, returning nil if missing or invalid
+	GetBytesHex(key string) []byte
+
 	// Keys returns all available configuration keys
 	Keys() []string
+
+	// ReferencedEnvVars returns the sorted, de-duplicated names of environment
+	// variables read by ${VAR} substitution while loading
+	ReferencedEnvVars() []string
+
+	// Conflicts returns the sorted keys set by more than one merged layer
+	// (base, profile, overrides, ...) when loaded with WithConflictReport;
+	// nil otherwise
+	Conflicts() []string
+
+	// Source reports where the value of key came from when loaded with
+	// WithProvenance: the file (or URL, or "defaults") that last set it,
+	// "${VAR} in <file>" for values produced by env substitution, or "Set".
+	// Returns "" for unknown keys or without WithProvenance.
+	Source(key string) string
+
+	// MarshalJSON encodes the configuration as nested JSON objects, with the
+	// values of keys registered through WithSecretKeys redacted
+	MarshalJSON() ([]byte, error)
+
+	// Set stores a value under key; nested maps are flattened below key
+	Set(key string, value interface{})
+
+	// Unmarshal populates a struct from this configuration using konfig tags
+	Unmarshal(target interface{}) error
+
+	// Reload re-reads the sources this configuration was loaded from
+	Reload() error
+
+	// Watch polls the sources every interval and applies changes like Reload
+	// until ctx is done; load failures are sent on the returned channel
+	Watch(ctx context.Context, interval, debounce time.Duration) (<-chan error, error)
+
+	// Close stops every watcher started with Watch and waits for them to
+	// exit; later Watch calls fail. Values stay readable from the last
+	// snapshot. Close is idempotent and always returns nil.
+	Close() error
+
+	// BindStruct populates target now and again after every successful Reload
+	BindStruct(target interface{}) error
 }
 
 // config implements the Config interface
+//
+// Values live in an immutable map behind an atomic pointer: readers never
+// lock, while Set and Reload build a modified copy and swap it in.
 type config struct {
-	data map[string]interface{}
-	mu   sync.RWMutex
+	data atomic.Pointer[map[string]interface{}]
+
+	// mu serializes writers (Set, Reload) so no update is lost
+	mu sync.Mutex
+
+	// envVars lists variables read during substitution; guarded by mu
+	envVars []string
+
+	// conflicts lists keys set by several merged layers; guarded by mu
+	conflicts []string
+
+	// sources maps keys to their origin for Source; nil without
+	// WithProvenance. Replaced, never modified, under mu.
+	sources map[string]string
+
+	// secretKeys are masked by MarshalJSON together with their subtrees; an
+	// empty key masks everything
+	secretKeys []string
+
+	// delimiter joins the segments of flattened keys; empty means "."
+	delimiter string
+
+	// keepEmptyItems makes GetStringSlice split scalar values verbatim
+	keepEmptyItems bool
+
+	// logger receives getter warnings; nil means slog.Default()
+	logger *slog.Logger
+
+	// source re-runs the loader that produced this config; nil when not reloadable
+	source func() (*config, error)
+
+	// bindMu serializes reloads and guards bindings
+	bindMu   sync.Mutex
+	bindings []interface{}
+
+	// watchMu guards watchers and closed; watchersDone tracks their goroutines
+	watchMu      sync.Mutex
+	watchers     map[*watcher]context.CancelFunc
+	watchersDone sync.WaitGroup
+	closed       bool
 }
 
 // ConfigError represents configuration-related errors with context
@@ -90,7 +319,7 @@ func (e *ConfigError) Unwrap() error {
 //	    log.Fatal(err)
 //	}
 //	port := cfg.GetString("server.port")
-func Load(filePath string) (Config, error) {
+func Load(filePath string, opts ...Option) (Config, error) {
 	if filePath == "" {
 		return nil, &ConfigError{
 			Type:    "validation_error",
@@ -99,19 +328,27 @@ func Load(filePath string) (Config, error) {
 		}
 	}
 
-	return loadFromFile(filePath)
+	o := applyOptions(opts)
+	load := withOverrides(func() (*config, error) { return loadFromFile(filePath, o) }, o)
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	cfg.source = load
+
+	return cfg, nil
 }
 
 // LoadWithProfile loads base configuration and profile-specific overrides
 //
 // It loads the base file first, then looks for a profile-specific file
-// with the pattern: base-{profile}.yaml
+// with the pattern: base-{profile}.yaml (see WithProfileSeparator)
 //
 // Example:
 //
 //	cfg, err := konfig.LoadWithProfile("./config/app.yaml", "dev")
 //	// Loads: ./config/app.yaml, then ./config/app-dev.yaml
-func LoadWithProfile(filePath, profile string) (Config, error) {
+func LoadWithProfile(filePath, profile string, opts ...Option) (Config, error) {
 	if filePath == "" {
 		return nil, &ConfigError{
 			Type:    "validation_error",
@@ -121,263 +358,2200 @@ func LoadWithProfile(filePath, profile string) (Config, error) {
 	}
 
 	if profile == "" {
-		return Load(filePath)
+		return Load(filePath, opts...)
 	}
 
-	// Load base configuration
-	cfg, err := loadFromFile(filePath)
+	o := applyOptions(opts)
+	load := withOverrides(func() (*config, error) { return loadWithProfile(filePath, profile, o) }, o)
+	cfg, err := load()
 	if err != nil {
 		return nil, err
 	}
+	cfg.source = load
 
-	// Generate profile file path
-	profilePath := generateProfilePath(filePath, profile)
+	return cfg, nil
+}
 
-	// Load profile configuration if it exists
-	if fileExists(profilePath) {
-		profileCfg, err := loadFromFile(profilePath)
+// LoadWithProfileAndOverrides loads base and profile configuration, then
+// applies an optional overrides file that wins over both
+//
+// Precedence, lowest first: base file, profile file, overrides file. A
+// missing overrides file is skipped, which makes it suitable for emergency
+// tweaks dropped in by operations. An empty profile skips the profile step.
+//
+// Example:
+//
+//	cfg, err := konfig.LoadWithProfileAndOverrides("./config/app.yaml", "prod", "/etc/app/overrides.yaml")
+func LoadWithProfileAndOverrides(filePath, profile, overridesPath string, opts ...Option) (Config, error) {
+	if filePath == "" {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    filePath,
+			Message: "file path cannot be empty",
+		}
+	}
+
+	o := applyOptions(opts)
+	load := withOverrides(func() (*config, error) {
+		cfg, err := loadWithProfile(filePath, profile, o)
 		if err != nil {
-			return nil, &ConfigError{
-				Type:    "parse_error",
-				Path:    profilePath,
-				Message: "failed to load profile configuration",
-				Cause:   err,
-			}
+			return nil, err
 		}
+		return applyOverridesFile(cfg, overridesPath, o)
+	}, o)
 
-		// Merge profile config over base config
-		cfg = mergeConfigs(cfg, profileCfg)
+	cfg, err := load()
+	if err != nil {
+		return nil, err
 	}
+	cfg.source = load
 
 	return cfg, nil
 }
 
-// LoadInto loads configuration into a struct using tags
+// LoadWithDefaults loads built-in default YAML, typically embedded with
+// go:embed, and merges an optional user file over it
 //
-// Struct fields should use `konfig:"key.path"` tags to map configuration keys.
-// Default values can be specified with `default:"value"` tags.
+// A missing user file (or an empty userPath) yields the defaults alone, so
+// the application works without any configuration. Both sources go through
+// the usual flattening and ${VAR} substitution, and Reload re-reads the user
+// file.
 //
 // Example:
 //
-//	type Config struct {
-//	    Port   int    `konfig:"server.port" default:"8080"`
-//	    Host   string `konfig:"server.host" default:"localhost"`
-//	    Debug  bool   `konfig:"debug" default:"false"`
-//	}
-//	var cfg Config
-//	err := konfig.LoadInto("./config/app.yaml", &cfg)
-func LoadInto(filePath string, target interface{}) error {
-	cfg, err := Load(filePath)
+//	//go:embed defaults.yaml
+//	var defaultConfig []byte
+//
+//	cfg, err := konfig.LoadWithDefaults(defaultConfig, "/etc/myapp/config.yaml")
+func LoadWithDefaults(defaults []byte, userPath string, opts ...Option) (Config, error) {
+	o := applyOptions(opts)
+	configMap, err := parseYAMLBytes(defaults, o)
 	if err != nil {
-		return err
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    "defaults",
+			Message: "failed to parse default configuration",
+			Cause:   err,
+		}
 	}
 
-	return populateStruct(cfg, target)
-}
+	load := withOverrides(func() (*config, error) {
+		cfg, err := buildConfig(configMap, "defaults", o)
+		if err != nil {
+			return nil, err
+		}
+		if userPath == "" || !fileExists(userPath) {
+			return cfg, nil
+		}
 
-// LoadIntoWithProfile loads configuration with profile support into a struct
-func LoadIntoWithProfile(filePath, profile string, target interface{}) error {
-	cfg, err := LoadWithProfile(filePath, profile)
+		userCfg, err := loadFromFile(userPath, o)
+		if err != nil {
+			return nil, err
+		}
+		return mergeConfigs(cfg, userCfg, o), nil
+	}, o)
+
+	cfg, err := load()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	cfg.source = load
 
-	return populateStruct(cfg, target)
+	return cfg, nil
 }
 
-// Implementation details
-
-func loadFromFile(filePath string) (*config, error) {
-	// Check if file exists and is readable
-	if !fileExists(filePath) {
+// LoadProfileVariant loads dir/base.yaml (or .yml) with the named profile
+//
+// Both profile naming conventions are resolved: base-profile.yaml is tried
+// first, then base.profile.yaml. Pass WithProfileSeparator to accept only one.
+//
+// Example:
+//
+//	cfg, err := konfig.LoadProfileVariant("./config", "app", "prod")
+//	// Loads: ./config/app.yaml, then ./config/app-prod.yaml or ./config/app.prod.yaml
+func LoadProfileVariant(dir, base, profile string, opts ...Option) (Config, error) {
+	if base == "" {
 		return nil, &ConfigError{
-			Type:    "file_not_found",
-			Path:    filePath,
-			Message: "configuration file not found",
+			Type:    "validation_error",
+			Path:    dir,
+			Message: "base name cannot be empty",
 		}
 	}
 
-	// Load and parse YAML
-	configMap, err := parseYAMLFile(filePath)
-	if err != nil {
-		return nil, &ConfigError{
-			Type:    "parse_error",
-			Path:    filePath,
-			Message: "failed to parse YAML file",
-			Cause:   err,
-		}
+	basePath := filepath.Join(dir, base+".yaml")
+	if ymlPath := filepath.Join(dir, base+".yml"); !fileExists(basePath) && fileExists(ymlPath) {
+		basePath = ymlPath
 	}
 
-	// Flatten nested keys into dot notation
-	flatMap := flattenMap(configMap, "")
+	opts = append([]Option{func(o *options) { o.profileSeparators = []string{"-", "."} }}, opts...)
+	return LoadWithProfile(basePath, profile, opts...)
+}
 
-	// Process environment variable substitutions
-	processedMap, err := processEnvSubstitutions(flatMap)
+// AvailableProfiles lists the profiles that have a file next to basePath
+//
+// For ./config/app.yaml it returns "dev" and "prod" when app-dev.yaml and
+// app-prod.yml exist. Names are sorted and de-duplicated; an empty slice is
+// returned when there are none. WithProfileSeparator changes the separator.
+func AvailableProfiles(basePath string, opts ...Option) ([]string, error) {
+	o := applyOptions(opts)
+	dir := filepath.Dir(basePath)
+	filename := filepath.Base(basePath)
+	nameWithoutExt := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, &ConfigError{
-			Type:    "parse_error",
-			Path:    filePath,
-			Message: "failed to process environment variable substitutions",
+			Type:    "file_not_found",
+			Path:    dir,
+			Message: "cannot read configuration directory",
 			Cause:   err,
 		}
 	}
 
-	return &config{
-		data: processedMap,
-	}, nil
-}
-
-func generateProfilePath(basePath, profile string) string {
-	dir := filepath.Dir(basePath)
-	filename := filepath.Base(basePath)
-	ext := filepath.Ext(filename)
-	nameWithoutExt := strings.TrimSuffix(filename, ext)
+	found := make(map[string]struct{})
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
 
-	// Try both extensions: same as base file, then the other YAML extension
-	extensions := []string{ext}
-	if ext == ".yml" {
-		extensions = append(extensions, ".yaml")
-	} else if ext == ".yaml" {
-		extensions = append(extensions, ".yml")
-	}
+		ext := filepath.Ext(entry.Name())
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
 
-	for _, tryExt := range extensions {
-		profileFilename := fmt.Sprintf("%s-%s%s", nameWithoutExt, profile, tryExt)
-		profilePath := filepath.Join(dir, profileFilename)
-		if fileExists(profilePath) {
-			return profilePath
+		stem := strings.TrimSuffix(entry.Name(), ext)
+		for _, separator := range o.profileSeparators {
+			if profile, ok := strings.CutPrefix(stem, nameWithoutExt+separator); ok && profile != "" {
+				found[profile] = struct{}{}
+			}
 		}
 	}
 
-	// Fallback to first extension if nothing found
-	profileFilename := fmt.Sprintf("%s-%s%s", nameWithoutExt, profile, extensions[0])
-	return filepath.Join(dir, profileFilename)
+	return sortedKeys(found), nil
 }
 
-func mergeConfigs(base, override *config) *config {
-	result := &config{
-		data: make(map[string]interface{}),
+// LoadInto loads configuration into a struct using tags
+//
+// Struct fields should use `konfig:"key.path"` tags to map configuration keys.
+// Default values can be specified with `default:"value"` tags. An `env:"NAME"`
+// tag makes a set, non-empty variable NAME win over both.
+//
+// Example:
+//
+//	type Config struct {
+//	    Port   int    `konfig:"server.port" default:"8080"`
+//	    Host   string `konfig:"server.host" default:"localhost"`
+//	    Debug  bool   `konfig:"debug" default:"false"`
+//	}
+//	var cfg Config
+//	err := konfig.LoadInto("./config/app.yaml", &cfg)
+func LoadInto(filePath string, target interface{}, opts ...Option) error {
+	cfg, err := Load(filePath, opts...)
+	if err != nil {
+		return err
 	}
 
-	// Copy base config
-	base.mu.RLock()
-	for key, value := range base.data {
-		result.data[key] = value
+	return unmarshalLoaded(&structPopulator{cfg: cfg}, filePath, target, applyOptions(opts))
+}
+
+// Report describes where LoadIntoReport found the value of each field
+type Report struct {
+	// Fields lists every mapped scalar field in struct order
+	Fields []FieldReport
+}
+
+// FieldSource says where LoadIntoReport found the value of a field
+type FieldSource string
+
+const (
+	FromConfig  FieldSource = "config"  // the configuration key
+	FromEnv     FieldSource = "env"     // the variable named by the env tag
+	FromDefault FieldSource = "default" // the default or defaultFunc tag
+	FromNone    FieldSource = "none"    // nothing; the field kept its value
+)
+
+// FieldReport is the origin of a single field
+type FieldReport struct {
+	Field  string // Go field path from the target type, e.g. Config.Primary.Host
+	Key    string // the config key the field maps
+	Source FieldSource
+}
+
+// Count returns how many fields got their value from source
+func (r Report) Count(source FieldSource) int {
+	n := 0
+	for _, field := range r.Fields {
+		if field.Source == source {
+			n++
+		}
 	}
-	base.mu.RUnlock()
+	return n
+}
 
-	// Override with profile config
-	override.mu.RLock()
-	for key, value := range override.data {
-		result.data[key] = value
+// LoadIntoReport is LoadInto that also reports, per field, whether its value
+// came from the configuration, an env tag, a default tag or nowhere
+//
+// Use it to audit how much of the effective configuration is implicit
+// defaults. The report is returned with any error so that fields populated
+// before the failure can still be inspected.
+//
+// Example:
+//
+//	report, err := konfig.LoadIntoReport("./config/app.yaml", &cfg)
+//	log.Printf("%d of %d fields use defaults", report.Count(konfig.FromDefault), len(report.Fields))
+func LoadIntoReport(filePath string, target interface{}, opts ...Option) (Report, error) {
+	cfg, err := Load(filePath, opts...)
+	if err != nil {
+		return Report{}, err
 	}
-	override.mu.RUnlock()
 
-	return result
+	report := &Report{}
+	err = unmarshalLoaded(&structPopulator{cfg: cfg, report: report}, filePath, target, applyOptions(opts))
+	return *report, err
 }
 
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
+// MergeInto loads configuration into a struct the caller has already filled,
+// overwriting only the fields whose config key is present
+//
+// Fields without a value in the file keep what the caller set: default and
+// defaultFunc tags are ignored, while an env tag whose variable is set still
+// wins as it does for LoadInto. Empty values count as absent.
+//
+// Example:
+//
+//	cfg := Config{Port: 8080, Host: "localhost"} // in-code defaults
+//	err := konfig.MergeInto("./config/app.yaml", &cfg)
+func MergeInto(filePath string, target interface{}, opts ...Option) error {
+	cfg, err := Load(filePath, opts...)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalLoaded(&structPopulator{cfg: cfg, ignoreDefaults: true}, filePath, target, applyOptions(opts))
 }
 
-// Config interface implementation
+// LoadIntoWithDefaults copies defaults, a struct or pointer to a struct of
+// the same type as target, into target and then overlays the fields whose
+// config key is present, as MergeInto does
+//
+// This suits defaults that tag strings cannot express, such as slices, maps
+// or computed values. The copy is shallow: slices and maps that the file
+// does not set are shared with defaults. target is only assigned once
+// loading and population succeed, so on any error it is left untouched.
+//
+// Example:
+//
+//	defaults := Config{Port: 8080, Hosts: []string{"localhost"}}
+//	var cfg Config
+//	err := konfig.LoadIntoWithDefaults("./config/app.yaml", defaults, &cfg)
+func LoadIntoWithDefaults(filePath string, defaults, target interface{}, opts ...Option) error {
+	elem, err := structElem(target)
+	if err != nil {
+		return err
+	}
 
-func (c *config) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	defaultValue := reflect.ValueOf(defaults)
+	for defaultValue.Kind() == reflect.Ptr && !defaultValue.IsNil() {
+		defaultValue = defaultValue.Elem()
+	}
+	if !defaultValue.IsValid() || defaultValue.Type() != elem.Type() {
+		return &ConfigError{
+			Type:    "validation_error",
+			Path:    "struct",
+			Message: fmt.Sprintf("defaults must be a %s, got %T", elem.Type(), defaults),
+		}
+	}
 
-	value, exists := c.data[key]
-	return value, exists
-}
+	cfg, err := Load(filePath, opts...)
+	if err != nil {
+		return err
+	}
 
-func (c *config) GetString(key string) string {
-	if value, exists := c.Get(key); exists {
-		return fmt.Sprintf("%v", value)
+	// Populate a copy so that a failure cannot leave target half-filled
+	populated := reflect.New(elem.Type())
+	populated.Elem().Set(defaultValue)
+	if err := unmarshalLoaded(&structPopulator{cfg: cfg, ignoreDefaults: true}, filePath, populated.Interface(), applyOptions(opts)); err != nil {
+		return err
 	}
-	return ""
+	elem.Set(populated.Elem())
+	return nil
 }
 
-func (c *config) GetInt(key string) int {
-	if value, exists := c.Get(key); exists {
-		if str := fmt.Sprintf("%v", value); str != "" {
-			if i, err := strconv.Atoi(str); err == nil {
-				return i
-			}
-		}
+// LoadIntoWithProfile loads configuration with profile support into a struct
+//
+// Fields are mapped from the merged configuration, so values from the profile
+// file take precedence over the base file exactly as they do for the getters.
+func LoadIntoWithProfile(filePath, profile string, target interface{}, opts ...Option) error {
+	cfg, err := LoadWithProfile(filePath, profile, opts...)
+	if err != nil {
+		return err
 	}
-	return 0
+
+	return unmarshalLoaded(&structPopulator{cfg: cfg}, filePath, target, applyOptions(opts))
 }
 
-func (c *config) GetBool(key string) bool {
-	if value, exists := c.Get(key); exists {
-		if str := fmt.Sprintf("%v", value); str != "" {
-			if b, err := strconv.ParseBool(str); err == nil {
-				return b
-			}
+// unmarshalLoaded populates target for the LoadInto family with p, logging
+// keys no field maps when WithWarnUnusedKeys is set
+func unmarshalLoaded(p *structPopulator, filePath string, target interface{}, o options) error {
+	p.implicitNames = o.implicitFieldNames
+	if o.warnUnusedKeys {
+		p.used = make(map[string]struct{})
+	}
+	if err := p.populate(target); err != nil {
+		return err
+	}
+
+	if p.used != nil {
+		for _, key := range p.unusedKeys() {
+			o.logger.Warn("unused configuration key", "key", key, "source", filePath)
 		}
 	}
-	return false
+	return checkKeyGroups(p.cfg, o.keyGroups)
 }
 
-func (c *config) GetFloat64(key string) float64 {
-	if value, exists := c.Get(key); exists {
-		if str := fmt.Sprintf("%v", value); str != "" {
-			if f, err := strconv.ParseFloat(str, 64); err == nil {
-				return f
+// checkKeyGroups enforces WithRequiredTogether and WithMutuallyExclusive
+func checkKeyGroups(cfg Config, groups []keyGroup) error {
+	for _, group := range groups {
+		var set, unset []string
+		for _, key := range group.keys {
+			if isKeySet(cfg, key) {
+				set = append(set, key)
+			} else {
+				unset = append(unset, key)
 			}
 		}
-	}
-	return 0.0
-}
 
-func (c *config) GetDuration(key string) time.Duration {
-	if value, exists := c.Get(key); exists {
-		if str := fmt.Sprintf("%v", value); str != "" {
-			if d, err := time.ParseDuration(str); err == nil {
-				return d
+		switch {
+		case group.exclusive && len(set) > 1:
+			return &ConfigError{
+				Type:    "validation_error",
+				Path:    strings.Join(group.keys, ", "),
+				Message: fmt.Sprintf("keys are mutually exclusive but %s are all set", strings.Join(set, ", ")),
+			}
+		case !group.exclusive && len(set) > 0 && len(unset) > 0:
+			return &ConfigError{
+				Type:    "validation_error",
+				Path:    strings.Join(group.keys, ", "),
+				Message: fmt.Sprintf("keys must be set together: %s set but %s missing", strings.Join(set, ", "), strings.Join(unset, ", ")),
 			}
 		}
 	}
-	return 0
+	return nil
 }
 
-func (c *config) GetStringWithDefault(key, defaultValue string) string {
-	if value := c.GetString(key); value != "" {
-		return value
+// isKeySet reports whether key has a non-empty value or a subtree
+func isKeySet(cfg Config, key string) bool {
+	if value, exists := cfg.Get(key); exists {
+		return value != nil && formatValue(value) != ""
 	}
-	return defaultValue
+	return len(cfg.GetAllWithPrefix(key)) > 0
 }
 
-func (c *config) GetIntWithDefault(key string, defaultValue int) int {
-	if value, exists := c.Get(key); exists && fmt.Sprintf("%v", value) != "" {
-		return c.GetInt(key)
+// LoadIntoTyped loads configuration into a struct after checking that every
+// mapped value is compatible with its field type
+//
+// Unlike LoadInto, which stops at the first conversion failure and rejects
+// nothing structurally, LoadIntoTyped reports every mismatch in a single
+// type_error (e.g. a YAML list mapped to an int field) and leaves target
+// untouched when any are found. Options apply as they do for LoadInto.
+func LoadIntoTyped(filePath string, target interface{}, opts ...Option) error {
+	cfg, err := Load(filePath, opts...)
+	if err != nil {
+		return err
 	}
-	return defaultValue
+
+	return unmarshalTyped(cfg, filePath, target, applyOptions(opts))
 }
 
-func (c *config) GetBoolWithDefault(key string, defaultValue bool) bool {
+// LoadAt loads a YAML file and exposes only the section at keyPath, with the
+// prefix removed, as the configuration root
+//
+// This suits configuration embedded in a larger document such as a Helm
+// values file. A missing section is reported as a file_not_found error whose
+// Path names the file and key path. Options, including WithSecretKeys, use
+// keys as written in the whole file. Reload re-reads the file and re-scopes.
+//
+// Example:
+//
+//	// values.yaml: myapp: {server: {port: 8080}}
+//	cfg, err := konfig.LoadAt("./values.yaml", "myapp")
+//	port := cfg.GetInt("server.port")
+func LoadAt(filePath, keyPath string, opts ...Option) (Config, error) {
+	if filePath == "" {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    filePath,
+			Message: "file path cannot be empty",
+		}
+	}
+
+	o := applyOptions(opts)
+	load := withOverrides(func() (*config, error) {
+		cfg, err := loadFromFile(filePath, o)
+		if err != nil {
+			return nil, err
+		}
+		if keyPath == "" {
+			return cfg, nil
+		}
+		if !cfg.HasPrefix(keyPath) {
+			return nil, &ConfigError{
+				Type:    "file_not_found",
+				Path:    filePath + "#" + keyPath,
+				Message: "configuration section not found",
+			}
+		}
+		return cfg.scoped(keyPath), nil
+	}, o)
+
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	cfg.source = load
+
+	return cfg, nil
+}
+
+// Reserved keys declaring the schema version of a configuration file; the
+// first one set is used
+const (
+	SchemaVersionKey = "konfig.version"
+	APIVersionKey    = "apiVersion"
+)
+
+// LoadWithSchema loads configuration from a single YAML file and rejects it
+// with a validation_error when it declares a schema version that is not in
+// supported
+//
+// The version is read from SchemaVersionKey, falling back to APIVersionKey;
+// with WithKeyDelimiter, SchemaVersionKey uses that delimiter too. A file
+// without either is treated as unversioned and accepted unless
+// WithStrictSchema is given. Other options apply as for Load, and Reload
+// repeats the check.
+//
+// Example:
+//
+//	cfg, err := konfig.LoadWithSchema("./config/app.yaml", []string{"v1", "v2"}, konfig.WithStrictSchema())
+func LoadWithSchema(filePath string, supported []string, opts ...Option) (Config, error) {
+	loaded, err := Load(filePath, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	strict := applyOptions(opts).strictSchema
+	cfg := loaded.(*config)
+	load := cfg.source
+	cfg.source = func() (*config, error) {
+		fresh, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSchemaVersion(fresh, filePath, supported, strict); err != nil {
+			return nil, err
+		}
+		return fresh, nil
+	}
+	if err := checkSchemaVersion(cfg, filePath, supported, strict); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// checkSchemaVersion validates the version declared by cfg against supported
+func checkSchemaVersion(cfg *config, filePath string, supported []string, strict bool) error {
+	var version string
+	schemaKey := strings.ReplaceAll(SchemaVersionKey, ".", cfg.sep())
+	for _, key := range []string{schemaKey, APIVersionKey} {
+		if version = strings.TrimSpace(cfg.GetString(key)); version != "" {
+			break
+		}
+	}
+
+	if version == "" {
+		if !strict {
+			return nil
+		}
+		return &ConfigError{
+			Type:    "validation_error",
+			Path:    filePath,
+			Message: fmt.Sprintf("missing schema version: set %s or %s", SchemaVersionKey, APIVersionKey),
+		}
+	}
+
+	for _, candidate := range supported {
+		if version == candidate {
+			return nil
+		}
+	}
+	return &ConfigError{
+		Type:    "validation_error",
+		Path:    filePath,
+		Message: fmt.Sprintf("unsupported schema version '%s' (supported: %s)", version, strings.Join(supported, ", ")),
+	}
+}
+
+// LoadStandard loads configuration for appName from the standard per-user and
+// system-wide locations, returning the first file found
+//
+// This is an opt-in convenience for CLI tools; all other loaders require an
+// explicit path. The search order is:
+//
+//	$XDG_CONFIG_HOME/{appName}/config.yaml
+//	$HOME/.config/{appName}/config.yaml
+//	/etc/{appName}/config.yaml
+//
+// A config.yml in the same directory is accepted as well.
+//
+// Example:
+//
+//	cfg, err := konfig.LoadStandard("mytool")
+func LoadStandard(appName string) (Config, error) {
+	if appName == "" || strings.ContainsAny(appName, `/\`) || strings.Contains(appName, "..") {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    appName,
+			Message: "application name must be a non-empty single path element",
+		}
+	}
+
+	candidates := standardConfigPaths(appName)
+	for _, path := range candidates {
+		if fileExists(path) {
+			return Load(path)
+		}
+	}
+
+	return nil, &ConfigError{
+		Type:    "file_not_found",
+		Path:    appName,
+		Message: fmt.Sprintf("no configuration file found (tried: %s)", strings.Join(candidates, ", ")),
+	}
+}
+
+// FromMap builds a Config from a nested map without touching the filesystem
+//
+// The map is flattened into dot-notation keys like a loaded file. ${VAR}
+// placeholders are kept verbatim unless WithEnvSubstitution(true) is passed.
+// Intended for tests and programmatic configuration.
+//
+// Example:
+//
+//	cfg := konfig.FromMap(map[string]interface{}{
+//	    "server": map[string]interface{}{"port": 8080},
+//	})
+func FromMap(m map[string]interface{}, opts ...Option) Config {
+	o := applyOptions(append([]Option{WithEnvSubstitution(false)}, opts...))
+
+	data := flattenMap(m, "", o.keyDelimiter)
+	referenced := make(map[string]struct{})
+	if o.envSubstitution {
+		data, _ = processEnvSubstitutions(data, o, referenced)
+	}
+
+	cfg := newConfig(data)
+	cfg.envVars = sortedKeys(referenced)
+	cfg.secretKeys = o.secretKeys
+	cfg.delimiter = o.keyDelimiter
+	cfg.keepEmptyItems = o.keepEmptySliceItems
+	cfg.logger = o.logger
+	return cfg
+}
+
+// GetAs returns the value stored at key asserted to T, or the zero value and
+// false when the key is missing or holds another type
+//
+// No conversion is done: YAML integers are int, floats float64, lists
+// []interface{}, and maps are flattened into their keys, so a section
+// cannot be read as a map. Use it for values of arbitrary types stored with
+// Set or FromMap.
+//
+// Example:
+//
+//	cfg.Set("clock", clock)
+//	c, ok := konfig.GetAs[Clock](cfg, "clock")
+func GetAs[T any](cfg Config, key string) (T, bool) {
+	var zero T
+	value, exists := cfg.Get(key)
+	if !exists {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+// Equal reports whether two configurations hold the same keys with deeply
+// equal values, regardless of how they were loaded
+//
+// Values are compared with reflect.DeepEqual, so types must match: an int 1
+// and a float 1.0 differ, floats compare exactly, and a NaN value is never
+// equal to anything, including another NaN. A nil Config equals only nil.
+func Equal(a, b Config) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	keys := a.Keys()
+	if len(keys) != len(b.Keys()) {
+		return false
+	}
+	for _, key := range keys {
+		aValue, _ := a.Get(key)
+		bValue, exists := b.Get(key)
+		if !exists || !reflect.DeepEqual(aValue, bValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// Implementation details
+
+// loadWithProfile loads the base file and merges the profile file over it
+func loadWithProfile(filePath, profile string, o options) (*config, error) {
+	// Load base configuration
+	cfg, err := loadFromFile(filePath, o)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		return cfg, nil
+	}
+	if o.profileSections {
+		cfg = applyProfileSection(cfg, profile, o)
+	}
+
+	// Generate profile file path
+	profilePath := generateProfilePath(filePath, profile, o.profileSeparators)
+
+	// Load profile configuration if it exists
+	if fileExists(profilePath) {
+		profileCfg, err := loadFromFile(profilePath, o)
+		if err != nil {
+			return nil, &ConfigError{
+				Type:    "parse_error",
+				Path:    profilePath,
+				Message: "failed to load profile configuration",
+				Cause:   err,
+			}
+		}
+
+		if o.profileSections {
+			profileCfg = applyProfileSection(profileCfg, profile, o)
+		}
+
+		// Merge profile config over base config
+		cfg = mergeConfigs(cfg, profileCfg, o)
+	} else if o.requiresProfileFile(profile) {
+		return nil, &ConfigError{
+			Type:    "file_not_found",
+			Path:    profilePath,
+			Message: fmt.Sprintf("profile file for required profile '%s' not found", profile),
+		}
+	}
+
+	return cfg, nil
+}
+
+// applyOverridesFile merges overridesPath over cfg when the file exists
+func applyOverridesFile(cfg *config, overridesPath string, o options) (*config, error) {
+	if overridesPath == "" || !fileExists(overridesPath) {
+		return cfg, nil
+	}
+
+	overridesCfg, err := loadFromFile(overridesPath, o)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    overridesPath,
+			Message: "failed to load overrides configuration",
+			Cause:   err,
+		}
+	}
+
+	return mergeConfigs(cfg, overridesCfg, o), nil
+}
+
+// withOverrides wraps load so that every load and reload ends by applying
+// the WithOverride values
+func withOverrides(load func() (*config, error), o options) func() (*config, error) {
+	if len(o.overrides) == 0 {
+		return load
+	}
+	return func() (*config, error) {
+		cfg, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return applyOverrides(cfg, o), nil
+	}
+}
+
+// applyOverrides sets the WithOverride values on cfg in the order given
+func applyOverrides(cfg *config, o options) *config {
+	for _, override := range o.overrides {
+		cfg.set(override.key, override.value, "WithOverride")
+	}
+	return cfg
+}
+
+// applyProfileSection merges the profiles.{profile} subtree of cfg over its
+// top level and drops the profiles section from the result
+func applyProfileSection(cfg *config, profile string, o options) *config {
+	sep := o.keyDelimiter
+	sectionPrefix := "profiles" + sep + profile + sep
+
+	base := make(map[string]interface{})
+	section := make(map[string]interface{})
+	for key, value := range cfg.snapshot() {
+		switch {
+		case strings.HasPrefix(key, sectionPrefix):
+			section[strings.TrimPrefix(key, sectionPrefix)] = value
+		case !strings.HasPrefix(key, "profiles"+sep):
+			base[key] = value
+		}
+	}
+
+	baseCfg := newConfig(base)
+	baseCfg.envVars = cfg.envVars
+	sectionCfg := newConfig(section)
+	if sources := cfg.sourceMap(); sources != nil {
+		baseCfg.sources = make(map[string]string, len(base))
+		sectionCfg.sources = make(map[string]string, len(section))
+		for key := range base {
+			baseCfg.sources[key] = sources[key]
+		}
+		for key := range section {
+			sectionCfg.sources[key] = sources[sectionPrefix+key]
+		}
+	}
+	return mergeConfigs(baseCfg, sectionCfg, o)
+}
+
+// standardConfigPaths lists the LoadStandard candidates in search order
+func standardConfigPaths(appName string) []string {
+	var dirs []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dirs = append(dirs, filepath.Join(xdg, appName))
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".config", appName))
+	}
+	dirs = append(dirs, filepath.Join("/etc", appName))
+
+	paths := make([]string, 0, len(dirs)*2)
+	for _, dir := range dirs {
+		paths = append(paths, filepath.Join(dir, "config.yaml"), filepath.Join(dir, "config.yml"))
+	}
+	return paths
+}
+
+func loadFromFile(filePath string, o options) (*config, error) {
+	// Check if file exists and is readable
+	if !fileExists(filePath) {
+		return nil, &ConfigError{
+			Type:    "file_not_found",
+			Path:    filePath,
+			Message: "configuration file not found",
+		}
+	}
+
+	// Load and parse YAML
+	configMap, err := parseYAMLFile(filePath, o)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    filePath,
+			Message: "failed to parse YAML file",
+			Cause:   err,
+		}
+	}
+
+	return buildConfig(configMap, filePath, o)
+}
+
+// buildConfig flattens a parsed document and applies env substitution;
+// origin names the file or URL in errors
+func buildConfig(configMap map[string]interface{}, origin string, o options) (*config, error) {
+	// Flatten nested keys into dot notation
+	flatMap, err := flattenMapLimited(configMap, "", o.keyDelimiter, o.maxKeys)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    origin,
+			Message: "configuration has too many keys",
+			Cause:   err,
+		}
+	}
+
+	// Process environment variable substitutions
+	referenced := make(map[string]struct{})
+	raw := flatMap
+	if o.envSubstitution {
+		flatMap, err = processEnvSubstitutions(flatMap, o, referenced)
+		if err != nil {
+			return nil, &ConfigError{
+				Type:    "parse_error",
+				Path:    origin,
+				Message: "failed to process environment variable substitutions",
+				Cause:   err,
+			}
+		}
+	}
+
+	applyKeyAliases(flatMap, origin, o.keyDelimiter, o.logger)
+
+	cfg := newConfig(flatMap)
+	if o.provenance {
+		cfg.sources = fileSources(flatMap, raw, origin, o.envSubstitution)
+	}
+	cfg.envVars = sortedKeys(referenced)
+	cfg.secretKeys = o.secretKeys
+	cfg.delimiter = o.keyDelimiter
+	cfg.keepEmptyItems = o.keepEmptySliceItems
+	cfg.logger = o.logger
+	return cfg, nil
+}
+
+// fileSources attributes every key of flat to origin, naming the variables
+// of keys whose raw value held ${VAR} placeholders when substituted
+func fileSources(flat, raw map[string]interface{}, origin string, substituted bool) map[string]string {
+	sources := make(map[string]string, len(flat))
+	for key := range flat {
+		sources[key] = origin
+		if !substituted {
+			continue
+		}
+		str, ok := raw[key].(string)
+		if !ok {
+			continue
+		}
+		var vars []string
+		for _, match := range envVarRegex.FindAllStringSubmatch(str, -1) {
+			vars = append(vars, "${"+match[1]+"}")
+		}
+		if len(vars) > 0 {
+			sources[key] = strings.Join(vars, ", ") + " in " + origin
+		}
+	}
+	return sources
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func generateProfilePath(basePath, profile string, separators []string) string {
+	dir := filepath.Dir(basePath)
+	filename := filepath.Base(basePath)
+	ext := filepath.Ext(filename)
+	nameWithoutExt := strings.TrimSuffix(filename, ext)
+
+	// Try both extensions: same as base file, then the other YAML extension
+	extensions := []string{ext}
+	if ext == ".yml" {
+		extensions = append(extensions, ".yaml")
+	} else if ext == ".yaml" {
+		extensions = append(extensions, ".yml")
+	}
+
+	for _, separator := range separators {
+		for _, tryExt := range extensions {
+			profileFilename := fmt.Sprintf("%s%s%s%s", nameWithoutExt, separator, profile, tryExt)
+			profilePath := filepath.Join(dir, profileFilename)
+			if fileExists(profilePath) {
+				return profilePath
+			}
+		}
+	}
+
+	// Fallback to first separator and extension if nothing found
+	profileFilename := fmt.Sprintf("%s%s%s%s", nameWithoutExt, separators[0], profile, extensions[0])
+	return filepath.Join(dir, profileFilename)
+}
+
+func mergeConfigs(base, override *config, o options) *config {
+	baseData := base.snapshot()
+	overrideData := override.snapshot()
+	merged := make(map[string]interface{}, len(baseData)+len(overrideData))
+
+	// Copy base config
+	for key, value := range baseData {
+		merged[key] = value
+	}
+
+	// Override with profile config
+	var conflicts map[string]struct{}
+	if o.conflictReport {
+		conflicts = make(map[string]struct{})
+		for _, key := range base.Conflicts() {
+			conflicts[key] = struct{}{}
+		}
+	}
+	for key, value := range overrideData {
+		if _, exists := merged[key]; exists && conflicts != nil {
+			conflicts[key] = struct{}{}
+		}
+		merged[key] = mergeValue(merged[key], value, o.arrayMerge)
+	}
+
+	envVars := make(map[string]struct{})
+	for _, name := range base.ReferencedEnvVars() {
+		envVars[name] = struct{}{}
+	}
+	for _, name := range override.ReferencedEnvVars() {
+		envVars[name] = struct{}{}
+	}
+
+	result := newConfig(merged)
+	result.envVars = sortedKeys(envVars)
+	if o.provenance {
+		result.sources = make(map[string]string)
+		for key, source := range base.sourceMap() {
+			result.sources[key] = source
+		}
+		for key, source := range override.sourceMap() {
+			result.sources[key] = source
+		}
+	}
+	if conflicts != nil {
+		result.conflicts = sortedKeys(conflicts)
+	}
+	result.secretKeys = o.secretKeys
+	result.delimiter = o.keyDelimiter
+	result.keepEmptyItems = o.keepEmptySliceItems
+	result.logger = o.logger
+	return result
+}
+
+// formatValue renders a stored value as a string; timestamps use RFC 3339
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		// %v renders whole floats such as 1.0e10 as "1e+10"; spell out IDs
+		// and counts instead
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// timeLayouts are the layouts accepted by parseTime, the YAML timestamp forms
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// timeValue converts a stored value to a time: native timestamps as is, other
+// values with layout, or with parseTime when layout is empty
+func timeValue(value interface{}, layout string) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	str := formatValue(value)
+	if layout == "" {
+		return parseTime(str)
+	}
+	t, err := time.Parse(layout, strings.TrimSpace(str))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot convert '%s' to time with layout '%s'", str, layout)
+	}
+	return t, nil
+}
+
+// parseTime parses RFC 3339 timestamps and plain dates
+func parseTime(s string) (time.Time, error) {
+	str := strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot convert '%s' to time", s)
+}
+
+// parseBool extends strconv.ParseBool with yes/no, y/n and on/off tokens
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(s))
+}
+
+// parsePercent parses "10%" as 0.1 and plain numbers as is
+func parsePercent(s string) (float64, error) {
+	str := strings.TrimSpace(s)
+	number, isPercent := strings.CutSuffix(str, "%")
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert '%s' to percent: %w", s, err)
+	}
+	if isPercent {
+		f /= 100
+	}
+	return f, nil
+}
+
+// countMultipliers maps the SI-style suffixes accepted by parseCount
+var countMultipliers = map[string]float64{
+	"k": 1e3,
+	"m": 1e6,
+	"g": 1e9,
+}
+
+// parseCount parses integers with an optional k, m or g suffix (case-insensitive)
+func parseCount(s string) (int64, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, fmt.Errorf("cannot convert empty value to count")
+	}
+
+	multiplier := 1.0
+	if m, ok := countMultipliers[strings.ToLower(str[len(str)-1:])]; ok {
+		multiplier = m
+		str = str[:len(str)-1]
+	}
+
+	if i, err := strconv.ParseInt(str, 10, 64); err == nil && multiplier == 1 {
+		return i, nil
+	}
+
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert '%s' to count: %w", s, err)
+	}
+	if math.IsNaN(f) {
+		return 0, fmt.Errorf("cannot convert '%s' to count", s)
+	}
+	count := f * multiplier
+	if count >= math.MaxInt64 || count < math.MinInt64 {
+		return 0, fmt.Errorf("cannot convert '%s' to count: value out of range", s)
+	}
+	return int64(count), nil
+}
+
+// byteUnits maps the size suffixes accepted by parseBytes: SI units are powers
+// of 1000 and IEC units powers of 1024
+var byteUnits = map[string]float64{
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// byteSizeRegex splits a size such as "1.5GiB" into number and unit
+var byteSizeRegex = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z]*)$`)
+
+// parseBytes parses sizes such as 512, 100MB or 1.5GiB into bytes; units are
+// case-insensitive and a bare number is a byte count
+func parseBytes(s string) (int64, error) {
+	match := byteSizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0, fmt.Errorf("cannot convert '%s' to bytes", s)
+	}
+
+	multiplier := 1.0
+	if match[2] != "" {
+		m, ok := byteUnits[strings.ToLower(match[2])]
+		if !ok {
+			return 0, fmt.Errorf("cannot convert '%s' to bytes: unknown unit '%s'", s, match[2])
+		}
+		multiplier = m
+	}
+
+	f, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert '%s' to bytes: %w", s, err)
+	}
+	size := f * multiplier
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("cannot convert '%s' to bytes: value out of range", s)
+	}
+	return int64(size), nil
+}
+
+// dayWeekUnitRegex matches the day and week components time.ParseDuration lacks
+var dayWeekUnitRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+
+// durationValue converts a stored value to a duration for GetDuration and
+// GetDurationSlice: time.Duration as is, numbers as seconds and anything else
+// through parseDuration
+func durationValue(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case int:
+		return unitsDuration(value, float64(v), time.Second)
+	case int64:
+		return unitsDuration(value, float64(v), time.Second)
+	case uint64:
+		return unitsDuration(value, float64(v), time.Second)
+	case float64:
+		return unitsDuration(value, v, time.Second)
+	}
+
+	// Quoted and ${VAR} values arrive as strings; bare numbers are seconds
+	// there too
+	str := fmt.Sprintf("%v", value)
+	if f, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+		return unitsDuration(value, f, time.Second)
+	}
+	return parseDuration(str)
+}
+
+// fieldDuration converts the value of a time.Duration struct field: stored
+// time.Duration values as is, bare numbers only in the unit of a unit tag and
+// anything else through parseDuration
+func fieldDuration(value interface{}, unit string) (time.Duration, error) {
+	if d, ok := value.(time.Duration); ok {
+		return d, nil
+	}
+
+	str := formatValue(value)
+	if u, ok := durationUnits[unit]; ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return unitsDuration(value, f, u)
+		}
+	}
+	return parseDuration(str)
+}
+
+// unitsDuration returns n units as a duration, rejecting results that do not
+// fit in time.Duration
+func unitsDuration(value interface{}, n float64, unit time.Duration) (time.Duration, error) {
+	d := n * float64(unit)
+	if math.IsNaN(d) || d >= math.MaxInt64 || d < math.MinInt64 {
+		return 0, fmt.Errorf("value '%v' is out of range for time.Duration", value)
+	}
+	return time.Duration(d), nil
+}
+
+// parseDuration extends time.ParseDuration with day (d = 24h) and week
+// (w = 7d) units, e.g. "1d12h" or "2w"
+func parseDuration(s string) (time.Duration, error) {
+	expanded := dayWeekUnitRegex.ReplaceAllStringFunc(s, func(match string) string {
+		parts := dayWeekUnitRegex.FindStringSubmatch(match)
+		n, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return match
+		}
+		hours := n * 24
+		if parts[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+
+	d, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("time: invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// mergeValue combines a base and override value for the same key
+func mergeValue(base, override interface{}, mode arrayMergeMode) interface{} {
+	baseList, baseIsList := base.([]interface{})
+	overrideList, overrideIsList := override.([]interface{})
+	if mode == arrayMergeReplace || !baseIsList || !overrideIsList {
+		return override
+	}
+
+	merged := append(make([]interface{}, 0, len(baseList)+len(overrideList)), baseList...)
+	for _, item := range overrideList {
+		if mode == arrayMergeAppendUnique && containsValue(merged, item) {
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func containsValue(list []interface{}, item interface{}) bool {
+	for _, existing := range list {
+		if reflect.DeepEqual(existing, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Config interface implementation
+
+func newConfig(data map[string]interface{}) *config {
+	c := &config{}
+	c.data.Store(&data)
+	return c
+}
+
+// sep returns the key delimiter
+func (c *config) sep() string {
+	if c.delimiter == "" {
+		return "."
+	}
+	return c.delimiter
+}
+
+// keyDelimiter returns the key delimiter of cfg, "." for foreign
+// implementations
+func keyDelimiter(cfg Config) string {
+	if c, ok := cfg.(*config); ok {
+		return c.sep()
+	}
+	return "."
+}
+
+// snapshot returns the current values; the returned map must not be modified
+func (c *config) snapshot() map[string]interface{} {
+	return *c.data.Load()
+}
+
+func (c *config) Get(key string) (interface{}, bool) {
+	value, exists := c.snapshot()[key]
+	return value, exists
+}
+
+func (c *config) GetString(key string) string {
+	if value, exists := c.Get(key); exists {
+		return formatValue(value)
+	}
+	return ""
+}
+
+func (c *config) GetMany(keys ...string) map[string]string {
+	values, _ := c.getMany(keys)
+	return values
+}
+
+func (c *config) GetManyE(keys ...string) (map[string]string, error) {
+	values, missing := c.getMany(keys)
+	if len(missing) > 0 {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    strings.Join(missing, ", "),
+			Message: "missing configuration keys",
+		}
+	}
+	return values, nil
+}
+
+func (c *config) getMany(keys []string) (map[string]string, []string) {
+	data := c.snapshot()
+	values := make(map[string]string, len(keys))
+	var missing []string
+	for _, key := range keys {
+		value, exists := data[key]
+		if !exists {
+			missing = append(missing, key)
+			values[key] = ""
+			continue
+		}
+		values[key] = formatValue(value)
+	}
+	return values, missing
+}
+
+func (c *config) GetTime(key string) time.Time {
+	value, exists := c.Get(key)
+	if !exists {
+		return time.Time{}
+	}
+	if t, ok := value.(time.Time); ok {
+		return t
+	}
+	t, err := parseTime(fmt.Sprintf("%v", value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (c *config) GetInt(key string) int {
+	value, _, _ := c.Int(key)
+	return value
+}
+
+func (c *config) Int(key string) (int, bool, error) {
+	value, exists := c.Get(key)
+	if !exists || value == nil || formatValue(value) == "" {
+		return 0, false, nil
+	}
+
+	i, err := integerValue(value, strconv.IntSize)
+	if err != nil {
+		message := fmt.Sprintf("value '%v' is not an integer", value)
+		if errors.Is(err, strconv.ErrRange) {
+			message = fmt.Sprintf("value '%v' is out of range for int", value)
+		}
+		return 0, true, &ConfigError{
+			Type:    "type_error",
+			Path:    key,
+			Message: message,
+			Cause:   err,
+		}
+	}
+	return int(i), true, nil
+}
+
+// intValue converts a YAML int, float or numeric string to an int
+func intValue(value interface{}) (int, bool) {
+	i, err := integerValue(value, strconv.IntSize)
+	return int(i), err == nil
+}
+
+// integerValue converts a YAML int, float or numeric string to an integer
+// of the given bit size, truncating floats; values outside the range fail
+// with an error wrapping strconv.ErrRange
+func integerValue(value interface{}, bits int) (int64, error) {
+	str := strings.TrimSpace(fmt.Sprintf("%v", value))
+	i, err := strconv.ParseInt(str, 10, bits)
+	if err == nil || errors.Is(err, strconv.ErrRange) {
+		return i, err
+	}
+
+	// YAML decodes 30.0 as a float; truncate rather than fail
+	f, floatErr := strconv.ParseFloat(str, 64)
+	if floatErr != nil && !errors.Is(floatErr, strconv.ErrRange) || math.IsNaN(f) {
+		return 0, err
+	}
+	limit := math.Ldexp(1, bits-1)
+	if floatErr != nil || f < -limit || f >= limit {
+		return 0, fmt.Errorf("value %q: %w", str, strconv.ErrRange)
+	}
+	return int64(f), nil
+}
+
+func (c *config) GetInt64(key string) int64 {
+	if value, exists := c.Get(key); exists {
+		if i, err := integerValue(value, 64); err == nil {
+			return i
+		}
+	}
+	return 0
+}
+
+func (c *config) GetBool(key string) bool {
+	b, _ := c.GetBoolE(key)
+	return b
+}
+
+func (c *config) GetBoolE(key string) (bool, error) {
+	value, exists := c.Get(key)
+	if !exists {
+		return false, nil
+	}
+
+	switch n := value.(type) {
+	case int:
+		return n != 0, nil
+	case int64:
+		return n != 0, nil
+	case uint64:
+		return n != 0, nil
+	case float64:
+		return n != 0, nil
+	}
+
+	b, err := parseBool(fmt.Sprintf("%v", value))
+	if err != nil {
+		return false, &ConfigError{
+			Type:    "type_error",
+			Path:    key,
+			Message: "value is not a recognizable boolean",
+			Cause:   err,
+		}
+	}
+	return b, nil
+}
+
+func (c *config) GetFloat64(key string) float64 {
+	f, _ := c.GetFloat64E(key)
+	return f
+}
+
+func (c *config) GetFloat64E(key string) (float64, error) {
+	value, exists := c.Get(key)
+	if !exists {
+		return 0, nil
+	}
+
+	str := fmt.Sprintf("%v", value)
+	f, err := strconv.ParseFloat(str, 64)
+	if err == nil {
+		return f, nil
+	}
+
+	message := "value is not a number"
+	if strings.Count(str, ",") == 1 {
+		message = fmt.Sprintf("value '%s' uses a comma; write decimals with a dot, e.g. '%s'", str, strings.Replace(str, ",", ".", 1))
+	} else if strings.Contains(str, ",") {
+		message = fmt.Sprintf("value '%s' contains commas, which are not decimal or thousands separators", str)
+	}
+	return 0, &ConfigError{
+		Type:    "type_error",
+		Path:    key,
+		Message: message,
+		Cause:   err,
+	}
+}
+
+func (c *config) GetDurationClamped(key string, min, max, def time.Duration) time.Duration {
+	value, exists := c.Get(key)
+	if !exists {
+		return def
+	}
+
+	d, err := durationValue(value)
+	if err != nil {
+		c.log().Warn("invalid duration, using default", "key", key, "value", formatValue(value), "default", def)
+		return def
+	}
+	switch {
+	case d < min:
+		c.log().Warn("duration below minimum, clamping", "key", key, "value", d, "min", min)
+		return min
+	case d > max:
+		c.log().Warn("duration above maximum, clamping", "key", key, "value", d, "max", max)
+		return max
+	}
+	return d
+}
+
+// log returns the logger for warnings raised after loading
+func (c *config) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
+
+func (c *config) GetTimeWithLayout(key, layout string) (time.Time, error) {
+	value, exists := c.Get(key)
+	if !exists {
+		return time.Time{}, nil
+	}
+
+	t, err := timeValue(value, layout)
+	if err != nil {
+		return time.Time{}, &ConfigError{
+			Type:    "type_error",
+			Path:    key,
+			Message: err.Error(),
+		}
+	}
+	return t, nil
+}
+
+func (c *config) GetDuration(key string) time.Duration {
+	if value, exists := c.Get(key); exists {
+		if d, err := durationValue(value); err == nil {
+			return d
+		}
+	}
+	return 0
+}
+
+func (c *config) GetStringWithDefault(key, defaultValue string) string {
+	if value := c.GetString(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (c *config) GetStringWithDefaultTrimmed(key, defaultValue string) string {
+	if value := c.GetString(key); strings.TrimSpace(value) != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (c *config) GetStringOrEnv(key, envVar string) string {
+	if value := c.GetString(key); value != "" {
+		return value
+	}
+	return os.Getenv(envVar)
+}
+
+func (c *config) GetIntWithDefault(key string, defaultValue int) int {
+	if value, exists := c.Get(key); exists && fmt.Sprintf("%v", value) != "" {
+		return c.GetInt(key)
+	}
+	return defaultValue
+}
+
+func (c *config) GetBoolWithDefault(key string, defaultValue bool) bool {
 	if value, exists := c.Get(key); exists && fmt.Sprintf("%v", value) != "" {
 		return c.GetBool(key)
 	}
-	return defaultValue
+	return defaultValue
+}
+
+func (c *config) GetPercent(key string) float64 {
+	if value, exists := c.Get(key); exists {
+		if f, err := parsePercent(fmt.Sprintf("%v", value)); err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+func (c *config) GetCount(key string) int64 {
+	if value, exists := c.Get(key); exists {
+		if n, err := parseCount(fmt.Sprintf("%v", value)); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+func (c *config) GetStringSlice(key string) []string {
+	values := c.sliceValues(key)
+	if values == nil {
+		value, exists := c.Get(key)
+		if !exists || value == nil {
+			return nil
+		}
+		if c.keepEmptyItems {
+			return splitItems(formatValue(value))
+		}
+		values = splitList(formatValue(value))
+	}
+	result := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			result[i] = fmt.Sprintf("%v", value)
+		}
+	}
+	return result
+}
+
+func (c *config) GetIntSlice(key string) []int {
+	values := c.sliceValues(key)
+	if values == nil {
+		return nil
+	}
+	result := make([]int, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		// Elements may mix YAML ints with strings from ${VAR} substitution
+		n, ok := intValue(value)
+		if !ok {
+			return nil
+		}
+		result[i] = n
+	}
+	return result
+}
+
+func (c *config) GetDurationSlice(key string) []time.Duration {
+	values := c.sliceValues(key)
+	if values == nil {
+		value, exists := c.Get(key)
+		if !exists {
+			return nil
+		}
+		values = splitList(fmt.Sprintf("%v", value))
+	}
+
+	durations, err := parseDurations(values, durationValue)
+	if err != nil {
+		return nil
+	}
+	return durations
+}
+
+// splitList splits a comma- or newline-separated scalar (such as a YAML
+// block string) into trimmed, non-empty items
+func splitList(s string) []interface{} {
+	items := []interface{}{}
+	for _, item := range splitItems(s) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitItems splits s at every comma and newline, keeping items verbatim
+func splitItems(s string) []string {
+	var items []string
+	for {
+		i := strings.IndexAny(s, ",\n")
+		if i < 0 {
+			return append(items, s)
+		}
+		items = append(items, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// parseDurations converts every item with convert
+func parseDurations(values []interface{}, convert func(interface{}) (time.Duration, error)) ([]time.Duration, error) {
+	durations := make([]time.Duration, len(values))
+	for i, value := range values {
+		d, err := convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert element %d '%v' to duration: %w", i, value, err)
+		}
+		durations[i] = d
+	}
+	return durations, nil
+}
+
+// sliceValues returns the list stored at key with any indexed keys
+// (key.0, key.1, ...) applied in ascending index order; gaps are left nil
+func (c *config) sliceValues(key string) []interface{} {
+	data := c.snapshot()
+
+	var result []interface{}
+	if list, ok := data[key].([]interface{}); ok {
+		result = append(make([]interface{}, 0, len(list)), list...)
+	}
+
+	keyPrefix := key + c.sep()
+	indexed := make(map[int]interface{})
+	var indices []int
+	for k, value := range data {
+		rest, found := strings.CutPrefix(k, keyPrefix)
+		if !found {
+			continue
+		}
+		index, err := strconv.Atoi(rest)
+		if err != nil || index < 0 || index > maxKeyCount {
+			continue
+		}
+		indexed[index] = value
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	for _, index := range indices {
+		for len(result) <= index {
+			result = append(result, nil)
+		}
+		result[index] = indexed[index]
+	}
+	return result
+}
+
+func (c *config) GetStringMap(key string) map[string]string {
+	prefix := key + c.sep()
+	result := make(map[string]string)
+	for k, value := range c.snapshot() {
+		if strings.HasPrefix(k, prefix) {
+			result[strings.TrimPrefix(k, prefix)] = formatValue(value)
+		}
+	}
+	return result
+}
+
+func (c *config) GetStringMapDepth(key string, depth int, collapse bool) map[string]string {
+	if depth < 1 {
+		return c.GetStringMap(key)
+	}
+
+	sep := c.sep()
+	prefix := key + sep
+	result := make(map[string]string)
+	subtrees := make(map[string]map[string]interface{})
+	for k, value := range c.snapshot() {
+		rest, found := strings.CutPrefix(k, prefix)
+		if !found {
+			continue
+		}
+		segments := strings.SplitN(rest, sep, depth+1)
+		if len(segments) <= depth {
+			result[rest] = formatValue(value)
+			continue
+		}
+		if !collapse {
+			continue
+		}
+		path := strings.Join(segments[:depth], sep)
+		if subtrees[path] == nil {
+			subtrees[path] = make(map[string]interface{})
+		}
+		subtrees[path][segments[depth]] = jsonValue(value)
+	}
+
+	for path, subtree := range subtrees {
+		if data, err := json.Marshal(unflattenMap(subtree, sep)); err == nil {
+			result[path] = string(data)
+		}
+	}
+	return result
+}
+
+func (c *config) GetStringMapInterface(key string) map[string]interface{} {
+	prefix := key + c.sep()
+	children := make(map[string]interface{})
+	for k, value := range c.snapshot() {
+		if rest, found := strings.CutPrefix(k, prefix); found {
+			children[rest] = value
+		}
+	}
+	return unflattenMap(children, c.sep())
+}
+
+func (c *config) GetStringMapE(key string) (map[string]string, error) {
+	sep := c.sep()
+	prefix := key + sep
+	result := make(map[string]string)
+	for k, value := range c.snapshot() {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		child := strings.TrimPrefix(k, prefix)
+		if strings.Contains(child, sep) {
+			return nil, &ConfigError{
+				Type:    "type_error",
+				Path:    k,
+				Message: fmt.Sprintf("'%s' is a nested map, not a string value of '%s'", strings.SplitN(child, sep, 2)[0], key),
+			}
+		}
+
+		switch value.(type) {
+		case []interface{}, map[string]interface{}, map[interface{}]interface{}:
+			return nil, &ConfigError{
+				Type:    "type_error",
+				Path:    k,
+				Message: fmt.Sprintf("value of type %T cannot be represented as a string", value),
+			}
+		}
+
+		result[child] = formatValue(value)
+	}
+	return result, nil
+}
+
+func (c *config) GetAllWithPrefix(prefix string) map[string]interface{} {
+	keyPrefix := prefix + c.sep()
+	result := make(map[string]interface{})
+	for key, value := range c.snapshot() {
+		if strings.HasPrefix(key, keyPrefix) {
+			result[strings.TrimPrefix(key, keyPrefix)] = value
+		}
+	}
+	return result
+}
+
+func (c *config) GetSubConfigs(prefix string) map[string]Config {
+	keyPrefix := prefix + c.sep()
+
+	children := make(map[string]struct{})
+	for key := range c.snapshot() {
+		rest, found := strings.CutPrefix(key, keyPrefix)
+		if !found {
+			continue
+		}
+		if child, _, nested := strings.Cut(rest, c.sep()); nested {
+			children[child] = struct{}{}
+		}
+	}
+
+	result := make(map[string]Config, len(children))
+	for child := range children {
+		result[child] = c.scoped(keyPrefix + child)
+	}
+	return result
+}
+
+func (c *config) HasPrefix(prefix string) bool {
+	keyPrefix := prefix + c.sep()
+	for key := range c.snapshot() {
+		if strings.HasPrefix(key, keyPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) Sub(prefix string) Config {
+	return c.scoped(prefix)
+}
+
+// scoped returns a detached config holding the values below prefix with the
+// prefix removed; it is not reloadable
+func (c *config) scoped(prefix string) *config {
+	keyPrefix := ""
+	if prefix != "" {
+		keyPrefix = prefix + c.sep()
+	}
+
+	data := make(map[string]interface{})
+	for key, value := range c.snapshot() {
+		if rest, found := strings.CutPrefix(key, keyPrefix); found {
+			data[rest] = value
+		}
+	}
+
+	scoped := newConfig(data)
+	scoped.delimiter = c.delimiter
+	scoped.keepEmptyItems = c.keepEmptyItems
+	scoped.logger = c.logger
+	if sources := c.sourceMap(); sources != nil {
+		scoped.sources = make(map[string]string, len(data))
+		for key := range data {
+			scoped.sources[key] = sources[keyPrefix+key]
+		}
+	}
+	for _, secret := range c.secretKeys {
+		if rest, found := strings.CutPrefix(secret, keyPrefix); found {
+			scoped.secretKeys = append(scoped.secretKeys, rest)
+		} else if secret == prefix || strings.HasPrefix(prefix, secret+c.sep()) {
+			// The whole scope lies below a secret key
+			scoped.secretKeys = []string{""}
+			break
+		}
+	}
+	return scoped
+}
+
+func (c *config) GetBytesBase64(key string) []byte {
+	if value, exists := c.Get(key); exists {
+		if decoded, err := base64.StdEncoding.DecodeString(fmt.Sprintf("%v", value)); err == nil {
+			return decoded
+		}
+	}
+	return nil
+}
+
+func (c *config) GetBytesHex(key string) []byte {
+	if value, exists := c.Get(key); exists {
+		if decoded, err := hex.DecodeString(fmt.Sprintf("%v", value)); err == nil {
+			return decoded
+		}
+	}
+	return nil
+}
+
+func (c *config) Keys() []string {
+	data := c.snapshot()
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Set stores value under key without blocking concurrent readers.
+//
+// A map value is flattened so Set("db", map[string]interface{}{"host": "x"})
+// is equivalent to Set("db.host", "x").
+func (c *config) Set(key string, value interface{}) {
+	c.set(key, value, "Set")
+}
+
+// set stores value under key, recording source as its provenance
+func (c *config) set(key string, value interface{}, source string) {
+	entries := map[string]interface{}{key: value}
+	if nested, ok := value.(map[string]interface{}); ok {
+		entries = flattenMap(nested, key, c.sep())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := c.snapshot()
+	updated := make(map[string]interface{}, len(current)+len(entries))
+	for k, v := range current {
+		updated[k] = v
+	}
+	for k, v := range entries {
+		updated[k] = v
+	}
+	c.data.Store(&updated)
+
+	if c.sources != nil {
+		sources := make(map[string]string, len(c.sources)+len(entries))
+		for k, source := range c.sources {
+			sources[k] = source
+		}
+		for k := range entries {
+			sources[k] = source
+		}
+		c.sources = sources
+	}
+}
+
+func (c *config) ReferencedEnvVars() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.envVars...)
+}
+
+func (c *config) Conflicts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conflicts == nil {
+		return nil
+	}
+	return append([]string(nil), c.conflicts...)
+}
+
+func (c *config) Source(key string) string {
+	return c.sourceMap()[key]
+}
+
+// sourceMap returns the current provenance map, which must not be modified
+func (c *config) sourceMap() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sources
+}
+
+func (c *config) Unmarshal(target interface{}) error {
+	return populateStruct(c, target)
+}
+
+// Reload re-reads the configuration sources and atomically replaces the values.
+//
+// Structs registered with BindStruct are re-populated from the new values. If
+// loading or populating any bound struct fails, the previous values are kept
+// and the error is returned.
+func (c *config) Reload() error {
+	if c.source == nil {
+		return &ConfigError{
+			Type:    "validation_error",
+			Path:    "config",
+			Message: "configuration has no reloadable source",
+		}
+	}
+
+	c.bindMu.Lock()
+	defer c.bindMu.Unlock()
+
+	fresh, err := c.source()
+	if err != nil {
+		return err
+	}
+
+	return c.apply(fresh)
 }
 
-func (c *config) Keys() []string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// apply swaps in the values of fresh and re-populates bound structs; the
+// caller must hold bindMu
+func (c *config) apply(fresh *config) error {
+	// Populate copies first so a failure leaves bound structs untouched
+	populated := make([]reflect.Value, len(c.bindings))
+	for i, target := range c.bindings {
+		copyPtr := reflect.New(reflect.TypeOf(target).Elem())
+		if err := populateStruct(fresh, copyPtr.Interface()); err != nil {
+			return err
+		}
+		populated[i] = copyPtr.Elem()
+	}
 
-	keys := make([]string, 0, len(c.data))
-	for key := range c.data {
-		keys = append(keys, key)
+	c.mu.Lock()
+	c.data.Store(fresh.data.Load())
+	c.envVars = fresh.envVars
+	c.conflicts = fresh.conflicts
+	c.sources = fresh.sources
+	c.mu.Unlock()
+
+	for i, target := range c.bindings {
+		reflect.ValueOf(target).Elem().Set(populated[i])
 	}
-	return keys
+
+	return nil
+}
+
+// BindStruct populates target and keeps it current across Reload calls.
+//
+// On every Reload the struct is rebuilt from the new values and its fields are
+// overwritten in place, so values set outside of konfig do not survive a
+// reload. Code reading the struct concurrently with a reload must provide its
+// own synchronization.
+func (c *config) BindStruct(target interface{}) error {
+	c.bindMu.Lock()
+	defer c.bindMu.Unlock()
+
+	if err := populateStruct(c, target); err != nil {
+		return err
+	}
+
+	c.bindings = append(c.bindings, target)
+	return nil
 }
 
 // populateStruct fills a struct using konfig tags
 func populateStruct(cfg Config, target interface{}) error {
+	return (&structPopulator{cfg: cfg}).populate(target)
+}
+
+// implicitFieldKey returns the key segment for an untagged field: its
+// snake_case name, or its lowercased name if only that one is set
+func (p *structPopulator) implicitFieldKey(prefix, name string) string {
+	snake, lower := snakeCase(name), strings.ToLower(name)
+	if snake == lower {
+		return snake
+	}
+
+	full := func(segment string) string {
+		if prefix == "" {
+			return segment
+		}
+		return prefix + keyDelimiter(p.cfg) + segment
+	}
+	if !isKeySet(p.cfg, full(snake)) && isKeySet(p.cfg, full(lower)) {
+		return lower
+	}
+	return snake
+}
+
+// snakeCase converts a Go identifier to snake_case, keeping acronyms together
+// ("HTTPPort" -> "http_port")
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// structPopulator carries per-call settings through the recursive struct walk
+type structPopulator struct {
+	cfg Config
+
+	// strictTypes rejects list and map values for scalar fields
+	strictTypes bool
+
+	// collect records field errors in errs instead of stopping at the first
+	collect bool
+	errs    []error
+
+	// used, when non-nil, records the config key of every mapped field
+	used map[string]struct{}
+
+	// ignoreDefaults leaves fields without a config value untouched
+	ignoreDefaults bool
+
+	// implicitNames maps untagged scalar fields by field name
+	implicitNames bool
+
+	// report, when non-nil, receives the source of every mapped field
+	report *Report
+
+	// path is the Go field path of the struct being populated, such as
+	// Config.Primary, so that reused struct types report distinct fields
+	path string
+
+	// depth is the number of structs on the current recursion path
+	depth int
+}
+
+// unusedKeys returns the sorted config keys not covered by a mapped field; a
+// field also covers the keys below its own (list elements, map entries)
+func (p *structPopulator) unusedKeys() []string {
+	var unused []string
+	for _, key := range p.cfg.Keys() {
+		covered := false
+		for prefix := key; ; {
+			if _, ok := p.used[prefix]; ok {
+				covered = true
+				break
+			}
+			i := strings.LastIndex(prefix, keyDelimiter(p.cfg))
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
+		}
+		if !covered {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+func (p *structPopulator) populate(target interface{}) error {
+	elem, err := structElem(target)
+	if err != nil {
+		return err
+	}
+
+	p.path = elem.Type().Name()
+	return p.populateFields(elem, elem.Type(), "")
+}
+
+// populateNested populates the nested struct field name at prefix
+func (p *structPopulator) populateNested(v reflect.Value, name, prefix string) error {
+	parent := p.path
+	p.path = p.fieldPath(name)
+	defer func() { p.path = parent }()
+
+	return p.populateFields(v, v.Type(), prefix)
+}
+
+// fieldPath returns the Go field path of field name in the current struct
+func (p *structPopulator) fieldPath(name string) string {
+	if p.path == "" {
+		return name
+	}
+	return p.path + "." + name
+}
+
+// record adds the source of a populated field to the report, if any
+func (p *structPopulator) record(name, configKey string, source FieldSource) {
+	if p.report == nil {
+		return
+	}
+	p.report.Fields = append(p.report.Fields, FieldReport{
+		Field:  p.fieldPath(name),
+		Key:    configKey,
+		Source: source,
+	})
+}
+
+// structElem validates that target is a non-nil pointer to a struct
+func structElem(target interface{}) (reflect.Value, error) {
 	if target == nil {
-		return &ConfigError{
+		return reflect.Value{}, &ConfigError{
 			Type:    "validation_error",
 			Path:    "struct",
 			Message: "target struct cannot be nil",
@@ -386,7 +2560,7 @@ func populateStruct(cfg Config, target interface{}) error {
 
 	v := reflect.ValueOf(target)
 	if v.Kind() != reflect.Ptr {
-		return &ConfigError{
+		return reflect.Value{}, &ConfigError{
 			Type:    "validation_error",
 			Path:    "struct",
 			Message: "target must be a pointer to struct",
@@ -395,88 +2569,415 @@ func populateStruct(cfg Config, target interface{}) error {
 
 	elem := v.Elem()
 	if elem.Kind() != reflect.Struct {
-		return &ConfigError{
+		return reflect.Value{}, &ConfigError{
 			Type:    "validation_error",
 			Path:    "struct",
 			Message: "target must be a pointer to struct",
 		}
 	}
 
-	return populateStructFields(cfg, elem, elem.Type(), "")
+	return elem, nil
 }
 
-func populateStructFields(cfg Config, v reflect.Value, t reflect.Type, prefix string) error {
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		fieldValue := v.Field(i)
+// unmarshalTyped checks every mapped key against its field type before
+// populating target, reporting all mismatches at once
+func unmarshalTyped(cfg Config, filePath string, target interface{}, o options) error {
+	elem, err := structElem(target)
+	if err != nil {
+		return err
+	}
+
+	checker := &structPopulator{cfg: cfg, strictTypes: true, collect: true, implicitNames: o.implicitFieldNames}
+	scratch := reflect.New(elem.Type()).Elem()
+	if err := checker.populateFields(scratch, scratch.Type(), ""); err != nil {
+		return err
+	}
+
+	if len(checker.errs) > 0 {
+		return &ConfigError{
+			Type:    "type_error",
+			Path:    elem.Type().Name(),
+			Message: fmt.Sprintf("%d field(s) incompatible with configuration", len(checker.errs)),
+			Cause:   errors.Join(checker.errs...),
+		}
+	}
+
+	return unmarshalLoaded(&structPopulator{cfg: cfg}, filePath, target, o)
+}
+
+func (p *structPopulator) populateFields(v reflect.Value, t reflect.Type, prefix string) error {
+	// Keys cannot nest deeper than maxNestingDepth, so deeper structs can
+	// never be mapped; the limit also bounds recursive types once pointer
+	// fields are followed, while finite recursive data stays allowed
+	if p.depth >= maxNestingDepth {
+		return &ConfigError{
+			Type:    "validation_error",
+			Path:    prefix,
+			Message: fmt.Sprintf("struct nesting exceeds maximum depth of %d", maxNestingDepth),
+		}
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+
+	for _, field := range structFields(t) {
+		fieldValue := v.Field(field.index)
 
 		if !fieldValue.CanSet() {
 			continue
 		}
 
-		// Get konfig tag
-		tag := field.Tag.Get("konfig")
+		tag := field.key
 		if tag == "" {
 			// Handle nested structs without explicit tags
-			if fieldValue.Kind() == reflect.Struct {
+			if field.nested {
 				nestedPrefix := prefix
 				if prefix != "" {
-					nestedPrefix = prefix + "."
+					nestedPrefix = prefix + keyDelimiter(p.cfg)
 				}
-				nestedPrefix += strings.ToLower(field.Name)
+				nestedPrefix += strings.ToLower(field.name)
 
-				if err := populateStructFields(cfg, fieldValue, fieldValue.Type(), nestedPrefix); err != nil {
+				if err := p.populateNested(fieldValue, field.name, nestedPrefix); err != nil {
 					return err
 				}
+				continue
 			}
-			continue
+			if !p.implicitNames || field.anonymous {
+				continue
+			}
+			tag = p.implicitFieldKey(prefix, field.name)
 		}
 
 		// Build full config key path
 		configKey := tag
 		if prefix != "" {
-			configKey = prefix + "." + tag
+			configKey = prefix + keyDelimiter(p.cfg) + tag
 		}
 
 		// Handle nested structs
-		if fieldValue.Kind() == reflect.Struct {
+		if field.nested {
 			// For nested structs, recursively populate using the config key as prefix
-			if err := populateStructFields(cfg, fieldValue, fieldValue.Type(), configKey); err != nil {
+			if err := p.populateNested(fieldValue, field.name, configKey); err != nil {
 				return err
 			}
-		} else {
-			// Get default value
-			defaultValue := field.Tag.Get("default")
+			continue
+		}
 
-			// Set scalar field value
-			if err := setFieldValue(cfg, fieldValue, configKey, defaultValue); err != nil {
-				return &ConfigError{
+		// Set scalar field value
+		if p.used != nil {
+			p.used[configKey] = struct{}{}
+		}
+		if p.setStringMap(fieldValue, configKey, field.tags) {
+			p.record(field.name, configKey, FromConfig)
+			continue
+		}
+		var fieldErr error
+		tags := field.tags
+		if p.ignoreDefaults {
+			// Fields absent from the file keep their current value
+			tags.defaultValue, tags.defaultFunc, tags.required = "", "", false
+		}
+		if err := validateFieldTags(tags); err != nil {
+			fieldErr = &ConfigError{
+				Type:    "validation_error",
+				Path:    fmt.Sprintf("%s.%s", t.Name(), field.name),
+				Message: "invalid struct tag",
+				Cause:   err,
+			}
+		} else {
+			var in fieldInput
+			var err error
+			if lookupTagEnv(tags.env) == "" {
+				// The shape only matters when the config value is used
+				err = p.checkValueShape(fieldValue, configKey, field.name)
+			}
+			if err == nil {
+				in, err = resolveFieldValue(p.cfg, configKey, tags)
+			}
+			if err == nil {
+				err = setFieldValue(p.cfg, fieldValue, configKey, in, tags)
+			}
+			if err == nil {
+				p.record(field.name, configKey, in.source)
+			}
+			var missing *missingValueError
+			if errors.As(err, &missing) {
+				message := fmt.Sprintf("required config key '%s' is not set", configKey)
+				if tags.env != "" {
+					message = fmt.Sprintf("required config key '%s' is not set and environment variable %s is empty", configKey, tags.env)
+				}
+				fieldErr = &ConfigError{
+					Type:    "validation_error",
+					Path:    fmt.Sprintf("%s.%s", t.Name(), field.name),
+					Message: message,
+					Cause:   missing.cause,
+				}
+			} else if err != nil {
+				message := conversionMessage(configKey, fieldValue.Type(), in, tags)
+				var unsupported *unsupportedTypeError
+				if errors.As(err, &unsupported) {
+					message = fmt.Sprintf("field of type %s (kind %s) cannot be set from config key '%s'",
+						fieldValue.Type(), fieldValue.Kind(), configKey)
+				}
+				fieldErr = &ConfigError{
 					Type:    "type_error",
-					Path:    fmt.Sprintf("%s.%s", t.Name(), field.Name),
-					Message: fmt.Sprintf("failed to set field from config key '%s'", configKey),
+					Path:    fmt.Sprintf("%s.%s", t.Name(), field.name),
+					Message: message,
 					Cause:   err,
 				}
 			}
 		}
+		if fieldErr != nil {
+			if !p.collect {
+				return fieldErr
+			}
+			p.errs = append(p.errs, fieldErr)
+		}
+	}
+
+	return nil
+}
+
+// setStringMap fills a map[string]string field from the flattened subtree at
+// configKey, as GetStringMap returns it, and reports whether it did; the
+// values have already been through ${VAR} substitution
+func (p *structPopulator) setStringMap(fieldValue reflect.Value, configKey string, tags fieldTags) bool {
+	fieldType := fieldValue.Type()
+	if fieldType.Kind() != reflect.Map || fieldType.Key().Kind() != reflect.String ||
+		fieldType.Elem().Kind() != reflect.String || tags.format != "" || lookupTagEnv(tags.env) != "" {
+		return false
+	}
+
+	values := p.cfg.GetStringMap(configKey)
+	if len(values) == 0 {
+		return false
+	}
+	m := reflect.MakeMapWithSize(fieldType, len(values))
+	for key, value := range values {
+		m.SetMapIndex(reflect.ValueOf(key).Convert(fieldType.Key()), reflect.ValueOf(value).Convert(fieldType.Elem()))
+	}
+	fieldValue.Set(m)
+	return true
+}
+
+// fieldDescriptor is the reflected, type-level part of a struct field that
+// populateFields needs; descriptors are cached per struct type
+type fieldDescriptor struct {
+	index     int
+	name      string
+	key       string // konfig tag; empty for untagged fields
+	anonymous bool
+	nested    bool // see isNestedStruct
+	tags      fieldTags
+}
+
+// fieldCache maps a struct reflect.Type to its []fieldDescriptor so repeated
+// population of the same type (LoadInto, BindStruct reloads) skips tag parsing
+var fieldCache sync.Map
+
+// structFields returns the cached field descriptors of struct type t
+func structFields(t reflect.Type) []fieldDescriptor {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]fieldDescriptor)
+	}
+
+	fields := make([]fieldDescriptor, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+		tags := parseFieldTags(field)
+		fields[i] = fieldDescriptor{
+			index:     i,
+			name:      field.Name,
+			key:       field.Tag.Get("konfig"),
+			anonymous: field.Anonymous,
+			// A struct decoded from one JSON value is set as a whole
+			nested: isNestedStruct(field.Type) && tags.format != "json",
+			tags:   tags,
+		}
+	}
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]fieldDescriptor)
+}
+
+// timeType is the type of time.Time fields, set as values rather than walked
+var timeType = reflect.TypeOf(time.Time{})
+
+// isNestedStruct reports whether a field is a struct whose fields are mapped
+// individually
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// checkValueShape rejects list and map values for scalar fields, which would
+// otherwise be converted from their %v rendering ("[8080]"); in strict mode a
+// flattened subtree at configKey counts as a map too
+func (p *structPopulator) checkValueShape(fieldValue reflect.Value, configKey, fieldName string) error {
+	value, exists := p.cfg.Get(configKey)
+	if !exists {
+		if !p.strictTypes {
+			return nil
+		}
+		// A flattened subtree means the key holds a map
+		prefix := configKey + keyDelimiter(p.cfg)
+		for _, key := range p.cfg.Keys() {
+			if strings.HasPrefix(key, prefix) {
+				return fmt.Errorf("config key '%s' is a map but field %s is %s", configKey, fieldName, fieldValue.Type())
+			}
+		}
+		return nil
+	}
+
+	switch value.(type) {
+	case []interface{}:
+		if fieldValue.Kind() != reflect.Slice {
+			return fmt.Errorf("config key '%s' is a list but field %s is %s", configKey, fieldName, fieldValue.Type())
+		}
+	case map[string]interface{}, map[interface{}]interface{}:
+		return fmt.Errorf("config key '%s' is a map but field %s is %s", configKey, fieldName, fieldValue.Type())
+	}
+	return nil
+}
+
+// fieldTags holds the konfig-related struct tags of a field other than the key
+type fieldTags struct {
+	defaultValue string   // default:"..."
+	defaultFunc  string   // defaultFunc:"..." registered function computing the default
+	format       string   // format:"..." value encoding, e.g. base64 or hex
+	env          string   // env:"..." variable that overrides the config value
+	transforms   []string // transform:"..." comma-separated stringTransforms names
+	layout       string   // layout:"..." time.Parse layout for time.Time fields
+	unit         string   // unit:"..." durationUnits name for bare numbers in time.Duration fields
+	required     bool     // required:"true" fails population when no value resolves
+}
+
+func parseFieldTags(field reflect.StructField) fieldTags {
+	tags := fieldTags{
+		defaultValue: field.Tag.Get("default"),
+		defaultFunc:  field.Tag.Get("defaultFunc"),
+		format:       field.Tag.Get("format"),
+		env:          field.Tag.Get("env"),
+		layout:       field.Tag.Get("layout"),
+		unit:         field.Tag.Get("unit"),
+		required:     field.Tag.Get("required") == "true",
+	}
+	for _, name := range strings.Split(field.Tag.Get("transform"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			tags.transforms = append(tags.transforms, name)
+		}
 	}
+	return tags
+}
 
+// stringTransforms are the normalizations available to the transform tag
+var stringTransforms = map[string]func(string) string{
+	"trim":   strings.TrimSpace,
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"expand": os.ExpandEnv,
+}
+
+// validateFieldTags rejects unknown transform and defaultFunc names
+func validateFieldTags(tags fieldTags) error {
+	for _, name := range tags.transforms {
+		if _, ok := stringTransforms[name]; !ok {
+			return fmt.Errorf("unknown transform: %s", name)
+		}
+	}
+	if tags.defaultFunc != "" {
+		if _, ok := lookupDefaultFunc(tags.defaultFunc); !ok {
+			return fmt.Errorf("unknown defaultFunc: %s", tags.defaultFunc)
+		}
+	}
+	if _, ok := durationUnits[tags.unit]; tags.unit != "" && !ok {
+		return fmt.Errorf("unknown unit: %s", tags.unit)
+	}
 	return nil
 }
 
-func setFieldValue(cfg Config, fieldValue reflect.Value, configKey, defaultValue string) error {
-	// Get value from config or use default
-	var strValue string
-	if value, exists := cfg.Get(configKey); exists && value != nil {
-		strValue = fmt.Sprintf("%v", value)
-	} else {
-		strValue = defaultValue
+// durationUnits are the units accepted by the unit tag
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// conversionMessage describes a value that cannot be converted to the field
+// type, naming where the value came from and the type expected
+func conversionMessage(configKey string, fieldType reflect.Type, in fieldInput, tags fieldTags) string {
+	from := fmt.Sprintf("config key '%s'", configKey)
+	switch in.source {
+	case FromEnv:
+		from = fmt.Sprintf("environment variable %s (config key '%s')", tags.env, configKey)
+	case FromDefault:
+		from = fmt.Sprintf("default tag of config key '%s'", configKey)
+	}
+
+	expected := fieldType.String()
+	if tags.format != "" {
+		expected += " in format " + tags.format
+	}
+	return fmt.Sprintf("failed to set field from %s: expected %s", from, expected)
+}
+
+// fieldInput is the raw value resolved for a field and where it came from
+type fieldInput struct {
+	str    string
+	list   []interface{} // set when the stored value is a list
+	native interface{}   // the stored value, unless transforms rewrite it
+	source FieldSource
+}
+
+// resolveFieldValue looks up the value for a field from the env tag's
+// variable, then config, then default, then defaultFunc, and applies the
+// transform tags; a missing required value is a missingValueError
+func resolveFieldValue(cfg Config, configKey string, tags fieldTags) (fieldInput, error) {
+	in := fieldInput{source: FromNone}
+	if envValue := lookupTagEnv(tags.env); envValue != "" {
+		in.str, in.source = envValue, FromEnv
+	} else if value, exists := cfg.Get(configKey); exists && value != nil {
+		in.str, in.source = formatValue(value), FromConfig
+		in.list, _ = value.([]interface{})
+		if len(tags.transforms) == 0 {
+			in.native = value
+		}
+	} else if tags.defaultValue != "" {
+		expanded, err := expandFileRefs(tags.defaultValue)
+		if err != nil && tags.required {
+			return in, &missingValueError{cause: err}
+		}
+		in.str, in.source = expanded, FromDefault
+	} else if fn, ok := lookupDefaultFunc(tags.defaultFunc); ok {
+		in.str, in.source = fn(), FromDefault
+	}
+
+	// Normalize the resolved value in tag order
+	for _, name := range tags.transforms {
+		in.str = stringTransforms[name](in.str)
 	}
 
+	if in.str == "" {
+		in.source = FromNone
+		if tags.required {
+			return in, &missingValueError{}
+		}
+	}
+	return in, nil
+}
+
+// setFieldValue converts the resolved value to the field type and stores it
+func setFieldValue(cfg Config, fieldValue reflect.Value, configKey string, in fieldInput, tags fieldTags) error {
+	strValue, listValue, nativeValue := in.str, in.list, in.native
+
 	// Skip if no value available
 	if strValue == "" {
 		return nil
 	}
 
+	if tags.format != "" {
+		return setFormattedValue(fieldValue, strValue, tags.format)
+	}
+
 	// Set value based on field type
 	switch fieldValue.Kind() {
 	case reflect.String:
@@ -485,20 +2986,30 @@ func setFieldValue(cfg Config, fieldValue reflect.Value, configKey, defaultValue
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		// Handle time.Duration specially
 		if fieldValue.Type() == reflect.TypeOf(time.Duration(0)) {
-			if d, err := time.ParseDuration(strValue); err == nil {
-				fieldValue.Set(reflect.ValueOf(d))
-			} else {
+			source := interface{}(strValue)
+			if nativeValue != nil {
+				source = nativeValue
+			}
+			d, err := fieldDuration(source, tags.unit)
+			if err != nil {
 				return fmt.Errorf("cannot convert '%s' to duration: %w", strValue, err)
 			}
-		} else if i, err := strconv.ParseInt(strValue, 10, 64); err == nil {
+			fieldValue.SetInt(int64(d))
+		} else if i, err := strconv.ParseInt(strValue, 10, fieldValue.Type().Bits()); err == nil {
 			fieldValue.SetInt(i)
+		} else if errors.Is(err, strconv.ErrRange) {
+			bits := fieldValue.Type().Bits()
+			return fmt.Errorf("value '%s' overflows %s (min %d, max %d)", strValue, fieldValue.Type(), int64(-1)<<(bits-1), int64(1)<<(bits-1)-1)
 		} else {
 			return fmt.Errorf("cannot convert '%s' to int: %w", strValue, err)
 		}
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if u, err := strconv.ParseUint(strValue, 10, 64); err == nil {
+		bits := fieldValue.Type().Bits()
+		if u, err := strconv.ParseUint(strValue, 10, bits); err == nil {
 			fieldValue.SetUint(u)
+		} else if errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("value '%s' overflows %s (max %d)", strValue, fieldValue.Type(), uint64(1)<<bits-1)
 		} else {
 			return fmt.Errorf("cannot convert '%s' to uint: %w", strValue, err)
 		}
@@ -511,28 +3022,195 @@ func setFieldValue(cfg Config, fieldValue reflect.Value, configKey, defaultValue
 		}
 
 	case reflect.Bool:
-		if b, err := strconv.ParseBool(strValue); err == nil {
+		// Same tokens as GetBool, so ops can write yes/no or on/off
+		if b, err := parseBool(strValue); err == nil {
 			fieldValue.SetBool(b)
 		} else {
 			return fmt.Errorf("cannot convert '%s' to bool: %w", strValue, err)
 		}
 
 	case reflect.Struct:
-		// Handle time.Duration specially
-		if fieldValue.Type() == reflect.TypeOf(time.Duration(0)) {
-			if d, err := time.ParseDuration(strValue); err == nil {
-				fieldValue.Set(reflect.ValueOf(d))
-			} else {
-				return fmt.Errorf("cannot convert '%s' to duration: %w", strValue, err)
+		// YAML timestamps are assigned as decoded; strings are parsed
+		if fieldValue.Type() == timeType {
+			source := interface{}(strValue)
+			if nativeValue != nil {
+				source = nativeValue
+			}
+			t, err := timeValue(source, tags.layout)
+			if err != nil {
+				return err
 			}
+			fieldValue.Set(reflect.ValueOf(t))
 		} else {
 			// Nested struct - recursive population
-			return populateStructFields(cfg, fieldValue, fieldValue.Type(), configKey)
+			return (&structPopulator{cfg: cfg}).populateFields(fieldValue, fieldValue.Type(), configKey)
+		}
+
+	case reflect.Slice:
+		switch {
+		case fieldValue.Type().Elem() == reflect.TypeOf(time.Duration(0)):
+			// Lists come from YAML sequences; env and default values are comma-separated
+			if listValue == nil {
+				listValue = splitList(strValue)
+			}
+			durations, err := parseDurations(listValue, func(item interface{}) (time.Duration, error) {
+				return fieldDuration(item, "")
+			})
+			if err != nil {
+				return err
+			}
+			fieldValue.Set(reflect.ValueOf(durations).Convert(fieldValue.Type()))
+		case fieldValue.Type().Elem() == timeType:
+			if listValue == nil {
+				listValue = splitList(strValue)
+			}
+			times := make([]time.Time, len(listValue))
+			for i, item := range listValue {
+				t, err := timeValue(item, tags.layout)
+				if err != nil {
+					return fmt.Errorf("element %d: %w", i, err)
+				}
+				times[i] = t
+			}
+			fieldValue.Set(reflect.ValueOf(times).Convert(fieldValue.Type()))
+		case fieldValue.Type().Elem().Kind() == reflect.String:
+			// YAML lists, comma-separated and newline-separated values all
+			// yield trimmed items with blanks dropped
+			if listValue == nil {
+				listValue = splitList(strValue)
+			}
+			items := reflect.MakeSlice(fieldValue.Type(), 0, len(listValue))
+			for _, item := range listValue {
+				if str := strings.TrimSpace(formatValue(item)); str != "" {
+					items = reflect.Append(items, reflect.ValueOf(str).Convert(fieldValue.Type().Elem()))
+				}
+			}
+			fieldValue.Set(items)
+		case fieldValue.Type().Elem().Kind() == reflect.Uint8:
+			fieldValue.SetBytes([]byte(strValue))
+		default:
+			return &unsupportedTypeError{}
+		}
+
+	default:
+		return &unsupportedTypeError{}
+	}
+
+	return nil
+}
+
+// unsupportedTypeError is returned by setFieldValue for field types it cannot
+// populate; populateFields adds the struct, field, type and key to the message
+type unsupportedTypeError struct{}
+
+func (e *unsupportedTypeError) Error() string {
+	return "unsupported field type"
+}
+
+// missingValueError is returned by setFieldValue for a required field without
+// a value; populateFields reports it as a validation_error
+type missingValueError struct {
+	cause error // why the default could not be resolved, if it was the reason
+}
+
+func (e *missingValueError) Error() string {
+	return "required value is missing"
+}
+
+// lookupTagEnv returns the value of the variable named by an env tag, if any
+func lookupTagEnv(name string) string {
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// setFormattedValue decodes strValue according to a format tag before assigning it
+func setFormattedValue(fieldValue reflect.Value, strValue, format string) error {
+	switch format {
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(strValue)
+		if err != nil {
+			return fmt.Errorf("cannot decode value as base64: %w", err)
+		}
+		return setBytesValue(fieldValue, decoded, format)
+
+	case "hex":
+		decoded, err := hex.DecodeString(strValue)
+		if err != nil {
+			return fmt.Errorf("cannot decode value as hex: %w", err)
+		}
+		return setBytesValue(fieldValue, decoded, format)
+
+	case "count":
+		n, err := parseCount(strValue)
+		if err != nil {
+			return err
+		}
+		return setIntegerValue(fieldValue, n, format)
+
+	case "json":
+		decoded := reflect.New(fieldValue.Type())
+		if err := json.Unmarshal([]byte(strValue), decoded.Interface()); err != nil {
+			return fmt.Errorf("cannot decode value as JSON: %w", err)
+		}
+		fieldValue.Set(decoded.Elem())
+		return nil
+
+	case "bytes":
+		n, err := parseBytes(strValue)
+		if err != nil {
+			return err
+		}
+		return setIntegerValue(fieldValue, n, format)
+
+	case "percent":
+		f, err := parsePercent(strValue)
+		if err != nil {
+			return err
 		}
+		if fieldValue.Kind() != reflect.Float32 && fieldValue.Kind() != reflect.Float64 {
+			return fmt.Errorf("format %s requires a float field, got %s", format, fieldValue.Type())
+		}
+		fieldValue.SetFloat(f)
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
 
+// setIntegerValue assigns a parsed integer to a signed or unsigned integer field
+func setIntegerValue(fieldValue reflect.Value, n int64, format string) error {
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fieldValue.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, fieldValue.Type())
+		}
+		fieldValue.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 {
+			return fmt.Errorf("cannot assign negative value %d to %s", n, fieldValue.Type())
+		}
+		if fieldValue.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d overflows %s", n, fieldValue.Type())
+		}
+		fieldValue.SetUint(uint64(n))
 	default:
-		return fmt.Errorf("unsupported field type: %s", fieldValue.Kind())
+		return fmt.Errorf("format %s requires an integer field, got %s", format, fieldValue.Type())
 	}
+	return nil
+}
 
+// setBytesValue assigns decoded bytes to a string or []byte field
+func setBytesValue(fieldValue reflect.Value, decoded []byte, format string) error {
+	switch {
+	case fieldValue.Kind() == reflect.String:
+		fieldValue.SetString(string(decoded))
+	case fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() == reflect.Uint8:
+		fieldValue.SetBytes(decoded)
+	default:
+		return fmt.Errorf("format %s requires a string or []byte field, got %s", format, fieldValue.Type())
+	}
 	return nil
 }
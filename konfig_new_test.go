@@ -1,9 +1,19 @@
 package konfig
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -173,3 +183,3409 @@ server:
 	// Default should be used for undefined variables
 	assert.Equal(t, "http", cfg.GetString("server.protocol"))
 }
+
+func TestNewAPI_LoadStandard(t *testing.T) {
+	xdgDir := t.TempDir()
+	homeDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv("HOME", homeDir)
+
+	// Only the $HOME candidate exists
+	homeConfigDir := filepath.Join(homeDir, ".config", "konfig-test-app")
+	require.NoError(t, os.MkdirAll(homeConfigDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(homeConfigDir, "config.yaml"), []byte("source: home"), 0644))
+
+	cfg, err := LoadStandard("konfig-test-app")
+	require.NoError(t, err)
+	assert.Equal(t, "home", cfg.GetString("source"))
+
+	// XDG location takes precedence once present
+	xdgConfigDir := filepath.Join(xdgDir, "konfig-test-app")
+	require.NoError(t, os.MkdirAll(xdgConfigDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(xdgConfigDir, "config.yaml"), []byte("source: xdg"), 0644))
+
+	cfg, err = LoadStandard("konfig-test-app")
+	require.NoError(t, err)
+	assert.Equal(t, "xdg", cfg.GetString("source"))
+
+	// Missing everywhere lists the paths tried
+	_, err = LoadStandard("konfig-missing-app")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file_not_found")
+	assert.Contains(t, err.Error(), filepath.Join(xdgDir, "konfig-missing-app", "config.yaml"))
+	assert.Contains(t, err.Error(), filepath.Join("/etc", "konfig-missing-app", "config.yaml"))
+
+	// Invalid application names are rejected
+	_, err = LoadStandard("../escape")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+}
+
+func TestNewAPI_DurationDayWeekUnits(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+timeouts:
+  short: 90m
+  long: 1d12h
+  retention: 2w
+  invalid: 3x
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, 90*time.Minute, cfg.GetDuration("timeouts.short"))
+	assert.Equal(t, 36*time.Hour, cfg.GetDuration("timeouts.long"))
+	assert.Equal(t, 14*24*time.Hour, cfg.GetDuration("timeouts.retention"))
+	assert.Equal(t, time.Duration(0), cfg.GetDuration("timeouts.invalid"))
+
+	type Timeouts struct {
+		Short     time.Duration `konfig:"short"`
+		Long      time.Duration `konfig:"long"`
+		Retention time.Duration `konfig:"retention"`
+		Grace     time.Duration `konfig:"grace" default:"1w"`
+	}
+	type Config struct {
+		Timeouts Timeouts `konfig:"timeouts"`
+	}
+
+	var target Config
+	require.NoError(t, populateStruct(cfg, &target))
+	assert.Equal(t, 90*time.Minute, target.Timeouts.Short)
+	assert.Equal(t, 36*time.Hour, target.Timeouts.Long)
+	assert.Equal(t, 14*24*time.Hour, target.Timeouts.Retention)
+	assert.Equal(t, 7*24*time.Hour, target.Timeouts.Grace)
+}
+
+func TestNewAPI_ReloadAndBindStruct(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 8080\n"), 0644))
+
+	type Config struct {
+		Port int    `konfig:"server.port"`
+		Host string `konfig:"server.host" default:"localhost"`
+	}
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	var bound Config
+	require.NoError(t, cfg.BindStruct(&bound))
+	assert.Equal(t, 8080, bound.Port)
+	assert.Equal(t, "localhost", bound.Host)
+
+	// A successful reload updates both the config and the bound struct
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 9090\n  host: example.com\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 9090, cfg.GetInt("server.port"))
+	assert.Equal(t, 9090, bound.Port)
+	assert.Equal(t, "example.com", bound.Host)
+
+	// A failed reload keeps the previous values everywhere
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: not-a-number\n"), 0644))
+	err = cfg.Reload()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+	assert.Equal(t, 9090, cfg.GetInt("server.port"))
+	assert.Equal(t, 9090, bound.Port)
+
+	// Unmarshal populates an independent struct from current values
+	var snapshot Config
+	require.NoError(t, cfg.Unmarshal(&snapshot))
+	assert.Equal(t, 9090, snapshot.Port)
+}
+
+func TestNewAPI_ReloadWithProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	profilePath := filepath.Join(tempDir, "app-dev.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte("env: base\nport: 8080\n"), 0644))
+	require.NoError(t, os.WriteFile(profilePath, []byte("env: dev\n"), 0644))
+
+	cfg, err := LoadWithProfile(basePath, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", cfg.GetString("env"))
+
+	require.NoError(t, os.WriteFile(profilePath, []byte("env: dev-reloaded\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, "dev-reloaded", cfg.GetString("env"))
+	assert.Equal(t, 8080, cfg.GetInt("port"))
+}
+
+func TestNewAPI_NonStringMapKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+2024: top-level
+releases:
+  2023: legacy
+  2024: current
+mixed:
+  1: one
+  name: two
+flags:
+  true: enabled
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "top-level", cfg.GetString("2024"))
+	assert.Equal(t, "legacy", cfg.GetString("releases.2023"))
+	assert.Equal(t, "current", cfg.GetString("releases.2024"))
+	assert.Equal(t, "one", cfg.GetString("mixed.1"))
+	assert.Equal(t, "two", cfg.GetString("mixed.name"))
+	assert.Equal(t, "enabled", cfg.GetString("flags.true"))
+
+	_, exists := cfg.Get("releases")
+	assert.False(t, exists, "integer-keyed mapping should be flattened, not stored whole")
+}
+
+func TestNewAPI_LoadIntoWithProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	profilePath := filepath.Join(tempDir, "app-prod.yaml")
+
+	require.NoError(t, os.WriteFile(basePath, []byte("server:\n  port: 8080\n  host: localhost\n"), 0644))
+	require.NoError(t, os.WriteFile(profilePath, []byte("server:\n  port: 443\n"), 0644))
+
+	type Config struct {
+		Server struct {
+			Port int    `konfig:"port"`
+			Host string `konfig:"host"`
+		} `konfig:"server"`
+	}
+
+	var cfg Config
+	require.NoError(t, LoadIntoWithProfile(basePath, "prod", &cfg))
+	assert.Equal(t, 443, cfg.Server.Port)
+	assert.Equal(t, "localhost", cfg.Server.Host)
+
+	// Without the profile the base value is used
+	var baseCfg Config
+	require.NoError(t, LoadIntoWithProfile(basePath, "", &baseCfg))
+	assert.Equal(t, 8080, baseCfg.Server.Port)
+}
+
+func TestNewAPI_Base64Format(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+tls:
+  key: c2VjcmV0LWtleQ==
+  cert: Y2VydGlmaWNhdGU=
+  broken: not*base64
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("secret-key"), cfg.GetBytesBase64("tls.key"))
+	assert.Nil(t, cfg.GetBytesBase64("tls.broken"))
+	assert.Nil(t, cfg.GetBytesBase64("tls.missing"))
+
+	type TLSConfig struct {
+		Key  []byte `konfig:"tls.key" format:"base64"`
+		Cert string `konfig:"tls.cert" format:"base64"`
+	}
+
+	var target TLSConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, []byte("secret-key"), target.Key)
+	assert.Equal(t, "certificate", target.Cert)
+
+	type BrokenConfig struct {
+		Key []byte `konfig:"tls.broken" format:"base64"`
+	}
+
+	var broken BrokenConfig
+	err = cfg.Unmarshal(&broken)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+	assert.Contains(t, err.Error(), "base64")
+}
+
+func TestNewAPI_SetIsVisibleToConcurrentReaders(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{"server.port": 8080})
+
+	cfg.Set("server.host", "localhost")
+	cfg.Set("database", map[string]interface{}{"pool": map[string]interface{}{"size": 10}})
+	assert.Equal(t, "localhost", cfg.GetString("server.host"))
+	assert.Equal(t, 10, cfg.GetInt("database.pool.size"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(n int) {
+			defer wg.Done()
+			cfg.Set(fmt.Sprintf("writer.%d", n), n)
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = cfg.GetInt("server.port")
+				_ = cfg.Keys()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		assert.Equal(t, i, cfg.GetInt(fmt.Sprintf("writer.%d", i)), "no concurrent Set may be lost")
+	}
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+}
+
+func TestNewAPI_GetStringMap(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+labels:
+  team: platform
+  tier: 1
+headers:
+  accept: application/json
+  retry:
+    max: 3
+hosts:
+  list: [a, b]
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"team": "platform", "tier": "1"}, cfg.GetStringMap("labels"))
+	assert.Equal(t, map[string]string{"accept": "application/json", "retry.max": "3"}, cfg.GetStringMap("headers"))
+	assert.Empty(t, cfg.GetStringMap("missing"))
+
+	labels, err := cfg.GetStringMapE("labels")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "platform", "tier": "1"}, labels)
+
+	_, err = cfg.GetStringMapE("headers")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+	assert.Contains(t, err.Error(), "retry")
+
+	_, err = cfg.GetStringMapE("hosts")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+}
+
+func TestNewAPI_LoadIntoTyped(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+server:
+  port: [8080, 8081]
+  host:
+    name: localhost
+  workers: four
+  debug: true
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	type Config struct {
+		Server struct {
+			Port    int    `konfig:"port"`
+			Host    string `konfig:"host"`
+			Workers int    `konfig:"workers"`
+			Debug   bool   `konfig:"debug"`
+		} `konfig:"server"`
+	}
+
+	var cfg Config
+	err = LoadIntoTyped(configPath, &cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+	assert.Contains(t, err.Error(), "3 field(s)")
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "server.host")
+	assert.Contains(t, err.Error(), "server.workers")
+	assert.False(t, cfg.Server.Debug, "target must not be partially populated")
+
+	validPath := filepath.Join(tempDir, "valid.yaml")
+	require.NoError(t, os.WriteFile(validPath, []byte("server:\n  port: 8080\n  debug: true\n"), 0644))
+
+	require.NoError(t, LoadIntoTyped(validPath, &cfg))
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.True(t, cfg.Server.Debug)
+
+	// Options apply as they do for LoadInto
+	var slashed struct {
+		Port int `konfig:"server/port"`
+	}
+	require.NoError(t, LoadIntoTyped(validPath, &slashed, WithKeyDelimiter("/")))
+	assert.Equal(t, 8080, slashed.Port)
+
+	err = LoadIntoTyped(validPath, &cfg, WithRequiredTogether("server.port", "server.host"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "keys must be set together")
+}
+
+func TestNewAPI_GetAllWithPrefix(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"database.host":         "localhost",
+		"database.port":         5432,
+		"database.options.ssl":  true,
+		"databases.replica.url": "postgres://replica",
+		"server.port":           8080,
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"host":        "localhost",
+		"port":        5432,
+		"options.ssl": true,
+	}, cfg.GetAllWithPrefix("database"))
+
+	result := cfg.GetAllWithPrefix("missing")
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
+
+func TestNewAPI_LoadProfileVariant(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app.yaml"), []byte("env: base\nport: 8080\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app.prod.yaml"), []byte("env: prod-dot\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-dev.yaml"), []byte("env: dev-dash\n"), 0644))
+
+	cfg, err := LoadProfileVariant(tempDir, "app", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-dot", cfg.GetString("env"))
+	assert.Equal(t, 8080, cfg.GetInt("port"))
+
+	cfg, err = LoadProfileVariant(tempDir, "app", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "dev-dash", cfg.GetString("env"))
+
+	// Restricting the separator ignores the other convention
+	cfg, err = LoadProfileVariant(tempDir, "app", "prod", WithProfileSeparator("-"))
+	require.NoError(t, err)
+	assert.Equal(t, "base", cfg.GetString("env"))
+
+	// The option applies to LoadWithProfile as well
+	cfg, err = LoadWithProfile(filepath.Join(tempDir, "app.yaml"), "prod", WithProfileSeparator("."))
+	require.NoError(t, err)
+	assert.Equal(t, "prod-dot", cfg.GetString("env"))
+
+	_, err = LoadProfileVariant(tempDir, "", "prod")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+}
+
+func TestNewAPI_GetBoolE(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"flags.native":   true,
+		"flags.yes":      "yes",
+		"flags.off":      "OFF",
+		"flags.explicit": "false",
+		"flags.typo":     "maybe",
+	})
+
+	tests := []struct {
+		key      string
+		expected bool
+		wantErr  bool
+	}{
+		{"flags.native", true, false},
+		{"flags.yes", true, false},
+		{"flags.off", false, false},
+		{"flags.explicit", false, false},
+		{"flags.typo", false, true},
+		{"flags.missing", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, err := cfg.GetBoolE(tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "type_error")
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, value)
+			assert.Equal(t, tt.expected, cfg.GetBool(tt.key))
+		})
+	}
+}
+
+func TestNewAPI_BoolTokensShared(t *testing.T) {
+	tests := []struct {
+		token    string
+		expected bool
+		wantErr  bool
+	}{
+		{"true", true, false},
+		{"yes", true, false},
+		{"Y", true, false},
+		{"On", true, false},
+		{"false", false, false},
+		{"NO", false, false},
+		{"n", false, false},
+		{"off", false, false},
+		{"maybe", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			cfg := FromMap(map[string]interface{}{"debug": tt.token})
+
+			getterValue, getterErr := cfg.GetBoolE("debug")
+
+			var target struct {
+				Debug bool `konfig:"debug"`
+			}
+			structErr := cfg.Unmarshal(&target)
+
+			if tt.wantErr {
+				for _, err := range []error{getterErr, structErr} {
+					var configErr *ConfigError
+					require.ErrorAs(t, err, &configErr)
+					assert.Equal(t, "type_error", configErr.Type)
+				}
+				return
+			}
+			require.NoError(t, getterErr)
+			require.NoError(t, structErr)
+			assert.Equal(t, tt.expected, getterValue)
+			assert.Equal(t, tt.expected, target.Debug)
+		})
+	}
+}
+
+func TestNewAPI_EnvTag(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+database:
+  url: postgres://file/app
+  pool: 5
+server:
+  port: 8080
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	t.Setenv("KONFIG_TEST_DATABASE_URL", "postgres://env/app")
+	t.Setenv("KONFIG_TEST_TIMEOUT", "45s")
+
+	type Config struct {
+		URL     string        `konfig:"database.url" env:"KONFIG_TEST_DATABASE_URL"`
+		Pool    int           `konfig:"database.pool" env:"KONFIG_TEST_UNSET_POOL" default:"10"`
+		Port    int           `konfig:"server.port" default:"3000"`
+		Timeout time.Duration `konfig:"server.timeout" env:"KONFIG_TEST_TIMEOUT" default:"10s"`
+		Workers int           `konfig:"server.workers" env:"KONFIG_TEST_UNSET_WORKERS" default:"4"`
+	}
+
+	var cfg Config
+	require.NoError(t, LoadInto(configPath, &cfg))
+
+	assert.Equal(t, "postgres://env/app", cfg.URL, "env beats config value")
+	assert.Equal(t, 5, cfg.Pool, "config value used when env var unset")
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, 45*time.Second, cfg.Timeout, "env beats default")
+	assert.Equal(t, 4, cfg.Workers, "default used when env and config absent")
+}
+
+func TestNewAPI_ReferencedEnvVars(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	baseContent := `
+database:
+  url: postgres://${DB_HOST:localhost}:${DB_PORT:5432}/app
+  password: ${DB_PASSWORD}
+server:
+  host: ${DB_HOST:localhost}
+  port: 8080
+`
+	require.NoError(t, os.WriteFile(basePath, []byte(baseContent), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("tracing:\n  endpoint: ${JAEGER_ENDPOINT}\n"), 0644))
+
+	cfg, err := Load(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DB_HOST", "DB_PASSWORD", "DB_PORT"}, cfg.ReferencedEnvVars())
+
+	cfg, err = LoadWithProfile(basePath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DB_HOST", "DB_PASSWORD", "DB_PORT", "JAEGER_ENDPOINT"}, cfg.ReferencedEnvVars())
+}
+
+func TestNewAPI_CountFormat(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"limits.connections": "10k",
+		"limits.requests":    "1.5M",
+		"limits.plain":       250,
+		"limits.invalid":     "ten",
+		"limits.nan":         "NaN",
+		"limits.inf":         "inf",
+		"limits.huge":        "1e30",
+		"limits.overflow":    "99999999999g",
+	})
+
+	assert.Equal(t, int64(10000), cfg.GetCount("limits.connections"))
+	assert.Equal(t, int64(1500000), cfg.GetCount("limits.requests"))
+	assert.Equal(t, int64(250), cfg.GetCount("limits.plain"))
+	assert.Equal(t, int64(0), cfg.GetCount("limits.invalid"))
+	assert.Equal(t, int64(0), cfg.GetCount("limits.missing"))
+	for _, key := range []string{"limits.nan", "limits.inf", "limits.huge", "limits.overflow"} {
+		assert.Equal(t, int64(0), cfg.GetCount(key), key)
+	}
+
+	type Limits struct {
+		Connections int    `konfig:"limits.connections" format:"count"`
+		Requests    uint64 `konfig:"limits.requests" format:"count"`
+		Backlog     int    `konfig:"limits.backlog" format:"count" default:"2k"`
+	}
+
+	var limits Limits
+	require.NoError(t, cfg.Unmarshal(&limits))
+	assert.Equal(t, 10000, limits.Connections)
+	assert.Equal(t, uint64(1500000), limits.Requests)
+	assert.Equal(t, 2000, limits.Backlog)
+
+	type Invalid struct {
+		Value int `konfig:"limits.invalid" format:"count"`
+	}
+	err := cfg.Unmarshal(&Invalid{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+
+	type Overflow struct {
+		Value int64 `konfig:"limits.overflow" format:"count"`
+	}
+	var overflow Overflow
+	err = cfg.Unmarshal(&overflow)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of range")
+	assert.Zero(t, overflow.Value)
+}
+
+func TestNewAPI_ArrayMergeModes(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte("allowed_hosts: [a.example.com, b.example.com]\nname: base\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("allowed_hosts: [b.example.com, prod.example.com]\nname: prod\n"), 0644))
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []interface{}
+	}{
+		{
+			name:     "replace by default",
+			expected: []interface{}{"b.example.com", "prod.example.com"},
+		},
+		{
+			name:     "append",
+			opts:     []Option{WithArrayMergeAppend()},
+			expected: []interface{}{"a.example.com", "b.example.com", "b.example.com", "prod.example.com"},
+		},
+		{
+			name:     "append unique",
+			opts:     []Option{WithArrayMergeAppendUnique()},
+			expected: []interface{}{"a.example.com", "b.example.com", "prod.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadWithProfile(basePath, "prod", tt.opts...)
+			require.NoError(t, err)
+
+			hosts, exists := cfg.Get("allowed_hosts")
+			require.True(t, exists)
+			assert.Equal(t, tt.expected, hosts)
+			assert.Equal(t, "prod", cfg.GetString("name"), "scalars are always replaced")
+		})
+	}
+}
+
+func TestNewAPI_GetStringWithDefaultTrimmed(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"blank":  "   ",
+		"tabs":   "\t\n",
+		"padded": "  value  ",
+		"empty":  "",
+	})
+
+	assert.Equal(t, "   ", cfg.GetStringWithDefault("blank", "fallback"))
+	assert.Equal(t, "fallback", cfg.GetStringWithDefaultTrimmed("blank", "fallback"))
+	assert.Equal(t, "fallback", cfg.GetStringWithDefaultTrimmed("tabs", "fallback"))
+	assert.Equal(t, "fallback", cfg.GetStringWithDefaultTrimmed("empty", "fallback"))
+	assert.Equal(t, "fallback", cfg.GetStringWithDefaultTrimmed("missing", "fallback"))
+	assert.Equal(t, "  value  ", cfg.GetStringWithDefaultTrimmed("padded", "fallback"))
+}
+
+func TestNewAPI_ProfileSections(t *testing.T) {
+	t.Setenv("KONFIG_TEST_PROD_HOST", "prod.example.com")
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+server:
+  port: 8080
+  host: localhost
+profiles:
+  prod:
+    server.port: 443
+    server:
+      host: ${KONFIG_TEST_PROD_HOST:prod.local}
+  dev:
+    debug: true
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := LoadWithProfile(configPath, "prod", WithProfileSections())
+	require.NoError(t, err)
+	assert.Equal(t, 443, cfg.GetInt("server.port"))
+	assert.Equal(t, "prod.example.com", cfg.GetString("server.host"))
+	_, exists := cfg.Get("debug")
+	assert.False(t, exists, "other profile sections must not apply")
+	_, exists = cfg.Get("profiles.dev.debug")
+	assert.False(t, exists, "profiles section is removed")
+
+	// A separate profile file still wins over the in-file section
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("server:\n  port: 8443\n"), 0644))
+	cfg, err = LoadWithProfile(configPath, "prod", WithProfileSections())
+	require.NoError(t, err)
+	assert.Equal(t, 8443, cfg.GetInt("server.port"))
+
+	// Without the option the section is plain configuration
+	cfg, err = LoadWithProfile(configPath, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	assert.True(t, cfg.GetBool("profiles.dev.debug"))
+}
+
+func TestNewAPI_WithEnvLookup(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+database:
+  host: ${KONFIG_LOOKUP_HOST:localhost}
+  port: ${KONFIG_LOOKUP_PORT:5432}
+  name: ${KONFIG_LOOKUP_NAME:app}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	env := map[string]string{
+		"KONFIG_LOOKUP_HOST": "db.test",
+		"KONFIG_LOOKUP_NAME": "",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	cfg, err := Load(configPath, WithEnvLookup(lookup))
+	require.NoError(t, err)
+
+	assert.Equal(t, "db.test", cfg.GetString("database.host"))
+	assert.Equal(t, 5432, cfg.GetInt("database.port"), "missing variable uses inline default")
+	assert.Equal(t, "app", cfg.GetString("database.name"), "empty variable uses inline default")
+}
+
+func TestNewAPI_PercentFormat(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"tracing.sample_rate": "10%",
+		"tracing.error_rate":  "2.5 %",
+		"tracing.fraction":    0.25,
+		"tracing.invalid":     "ten%",
+	})
+
+	assert.InDelta(t, 0.10, cfg.GetPercent("tracing.sample_rate"), 1e-9)
+	assert.InDelta(t, 0.025, cfg.GetPercent("tracing.error_rate"), 1e-9)
+	assert.InDelta(t, 0.25, cfg.GetPercent("tracing.fraction"), 1e-9)
+	assert.Equal(t, 0.0, cfg.GetPercent("tracing.invalid"))
+	assert.Equal(t, 0.0, cfg.GetPercent("tracing.missing"))
+
+	type Tracing struct {
+		SampleRate float64 `konfig:"tracing.sample_rate" format:"percent"`
+		Fraction   float32 `konfig:"tracing.fraction" format:"percent"`
+		Fallback   float64 `konfig:"tracing.fallback" format:"percent" default:"50%"`
+	}
+
+	var tracing Tracing
+	require.NoError(t, cfg.Unmarshal(&tracing))
+	assert.InDelta(t, 0.10, tracing.SampleRate, 1e-9)
+	assert.InDelta(t, 0.25, tracing.Fraction, 1e-6)
+	assert.InDelta(t, 0.5, tracing.Fallback, 1e-9)
+
+	type Invalid struct {
+		Rate int `konfig:"tracing.sample_rate" format:"percent"`
+	}
+	err := cfg.Unmarshal(&Invalid{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a float field")
+}
+
+func TestNewAPI_AvailableProfiles(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	for _, name := range []string{"app.yaml", "app-prod.yaml", "app-dev.yml", "app-dev.yaml", "app.staging.yaml", "other-qa.yaml", "app-notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte("key: value"), 0644))
+	}
+
+	profiles, err := AvailableProfiles(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, profiles)
+
+	profiles, err = AvailableProfiles(basePath, WithProfileSeparator("."))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"staging"}, profiles)
+
+	emptyDir := t.TempDir()
+	profiles, err = AvailableProfiles(filepath.Join(emptyDir, "app.yaml"))
+	require.NoError(t, err)
+	assert.NotNil(t, profiles)
+	assert.Empty(t, profiles)
+
+	_, err = AvailableProfiles(filepath.Join(tempDir, "missing", "app.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file_not_found")
+}
+
+func TestNewAPI_LoadWithProfileAndOverrides(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	overridesPath := filepath.Join(tempDir, "overrides.yaml")
+
+	require.NoError(t, os.WriteFile(basePath, []byte("server:\n  port: 8080\n  host: localhost\nlog: info\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("server:\n  port: 443\nlog: warn\n"), 0644))
+
+	// Missing overrides file is skipped
+	cfg, err := LoadWithProfileAndOverrides(basePath, "prod", overridesPath)
+	require.NoError(t, err)
+	assert.Equal(t, 443, cfg.GetInt("server.port"))
+	assert.Equal(t, "warn", cfg.GetString("log"))
+
+	require.NoError(t, os.WriteFile(overridesPath, []byte("log: debug\n"), 0644))
+
+	cfg, err = LoadWithProfileAndOverrides(basePath, "prod", overridesPath)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.GetString("server.host"), "base value")
+	assert.Equal(t, 443, cfg.GetInt("server.port"), "profile value")
+	assert.Equal(t, "debug", cfg.GetString("log"), "overrides win over profile")
+
+	// Reload re-applies all three tiers
+	require.NoError(t, os.WriteFile(overridesPath, []byte("server:\n  port: 9443\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 9443, cfg.GetInt("server.port"))
+	assert.Equal(t, "warn", cfg.GetString("log"))
+
+	// Malformed overrides are reported
+	require.NoError(t, os.WriteFile(overridesPath, []byte("invalid: [unclosed"), 0644))
+	_, err = LoadWithProfileAndOverrides(basePath, "prod", overridesPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load overrides configuration")
+}
+
+func TestNewAPI_HexFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+crypto:
+  key: "736563726574"
+  hash: "DEADBEEF"
+  odd: "abc"
+  broken: "zz"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("secret"), cfg.GetBytesHex("crypto.key"))
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, cfg.GetBytesHex("crypto.hash"))
+	assert.Nil(t, cfg.GetBytesHex("crypto.odd"))
+	assert.Nil(t, cfg.GetBytesHex("crypto.missing"))
+
+	type CryptoConfig struct {
+		Key  []byte `konfig:"crypto.key" format:"hex"`
+		Hash []byte `konfig:"crypto.hash" format:"hex"`
+	}
+
+	var target CryptoConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, []byte("secret"), target.Key)
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, target.Hash)
+
+	for _, key := range []string{"crypto.odd", "crypto.broken"} {
+		var broken struct {
+			Value []byte `konfig:"value" format:"hex"`
+		}
+		sub := newConfig(map[string]interface{}{"value": cfg.GetString(key)})
+		err := sub.Unmarshal(&broken)
+		require.Error(t, err, key)
+		assert.Contains(t, err.Error(), "type_error")
+		assert.Contains(t, err.Error(), "hex")
+	}
+}
+
+func TestNewAPI_SliceGetters(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+
+	require.NoError(t, os.WriteFile(basePath, []byte("ports: [8080, 8081, 8082]\nhosts: [a, b]\nempty: []\nname: app\nmixed: [1, two]\n"), 0644))
+	// The profile overrides a single element by index
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("ports:\n  1: 9091\nhosts:\n  3: d\n"), 0644))
+
+	cfg, err := Load(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, []int{8080, 8081, 8082}, cfg.GetIntSlice("ports"))
+	assert.Equal(t, []string{"8080", "8081", "8082"}, cfg.GetStringSlice("ports"))
+	assert.Equal(t, []string{}, cfg.GetStringSlice("empty"))
+	assert.Equal(t, []string{"app"}, cfg.GetStringSlice("name"))
+	assert.Nil(t, cfg.GetStringSlice("missing"))
+	assert.Nil(t, cfg.GetIntSlice("mixed"))
+
+	cfg, err = LoadWithProfile(basePath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []int{8080, 9091, 8082}, cfg.GetIntSlice("ports"))
+	assert.Equal(t, []string{"a", "b", "", "d"}, cfg.GetStringSlice("hosts"))
+
+	// Indexed keys alone are enough to build a slice
+	cfg.Set("workers.1", 2)
+	cfg.Set("workers.0", 1)
+	assert.Equal(t, []int{1, 2}, cfg.GetIntSlice("workers"))
+}
+
+func TestNewAPI_StringSliceSplitting(t *testing.T) {
+	values := map[string]interface{}{
+		"plain":     "a,b,c",
+		"spaces":    "  a , b ,c  ",
+		"doubled":   "a,,b,,,c",
+		"edges":     ",a,b,c,",
+		"blank":     " , ",
+		"single":    "a",
+		"list":      []interface{}{" a ", "b"},
+		"multiline": "a\nb\n\nc\n",
+	}
+
+	cfg := FromMap(values)
+	for _, key := range []string{"plain", "spaces", "doubled", "edges", "multiline"} {
+		assert.Equal(t, []string{"a", "b", "c"}, cfg.GetStringSlice(key), key)
+	}
+	assert.Empty(t, cfg.GetStringSlice("blank"))
+	assert.Equal(t, []string{"a"}, cfg.GetStringSlice("single"))
+	assert.Equal(t, []string{" a ", "b"}, cfg.GetStringSlice("list"), "list elements are kept as is")
+
+	raw := FromMap(values, WithKeepEmptySliceItems())
+	assert.Equal(t, []string{"  a ", " b ", "c  "}, raw.GetStringSlice("spaces"))
+	assert.Equal(t, []string{"a", "", "b", "", "", "c"}, raw.GetStringSlice("doubled"))
+	assert.Equal(t, []string{"", "a", "b", "c", ""}, raw.GetStringSlice("edges"))
+	assert.Equal(t, []string{"a", "b", "", "c", ""}, raw.GetStringSlice("multiline"), "same separators as the default")
+}
+
+func TestNewAPI_MultilineStrings(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+
+	cert := "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n  indented line\n\n-----END CERTIFICATE-----\n"
+	configContent := `
+tls:
+  cert: |
+    -----BEGIN CERTIFICATE-----
+    MIIBszCCAVmgAwIBAgIU
+      indented line
+
+    -----END CERTIFICATE-----
+  kept: |+
+    trailing
+
+  stripped: |-
+    no newline
+db:
+  query: >
+    SELECT *
+    FROM users
+
+    WHERE id = ${USER_ID:42}
+`
+	require.NoError(t, os.WriteFile(basePath, []byte(configContent), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("server:\n  port: 443\n"), 0644))
+
+	cfg, err := LoadWithProfile(basePath, "prod")
+	require.NoError(t, err)
+
+	assert.Equal(t, cert, cfg.GetString("tls.cert"))
+	assert.Equal(t, "trailing\n\n", cfg.GetString("tls.kept"))
+	assert.Equal(t, "no newline", cfg.GetString("tls.stripped"))
+	// Folded blocks keep their paragraph break; substitution leaves the rest intact
+	assert.Equal(t, "SELECT * FROM users\nWHERE id = 42\n", cfg.GetString("db.query"))
+
+	type TLSConfig struct {
+		Cert string `konfig:"tls.cert"`
+	}
+	var target TLSConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, cert, target.Cert)
+}
+
+func TestNewAPI_GetStringOrEnv(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"database.host": "db.internal",
+		"database.user": "",
+	})
+
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("DB_USER", "env-user")
+
+	assert.Equal(t, "db.internal", cfg.GetStringOrEnv("database.host", "DB_HOST"), "config wins")
+	assert.Equal(t, "env-user", cfg.GetStringOrEnv("database.user", "DB_USER"), "empty config falls back")
+	assert.Equal(t, "", cfg.GetStringOrEnv("database.password", "KONFIG_TEST_UNSET_VAR"))
+
+	// The environment is read on every call, not captured at load time
+	t.Setenv("DB_PASSWORD", "s3cret")
+	assert.Equal(t, "s3cret", cfg.GetStringOrEnv("database.password", "DB_PASSWORD"))
+}
+
+func TestNewAPI_TransformTag(t *testing.T) {
+	t.Setenv("REGION", "eu-west-1")
+	cfg := newConfig(map[string]interface{}{
+		"app.environment": "  PROD ",
+		"app.endpoint":    "https://$REGION.example.com",
+		"app.code":        " ab ",
+		"app.blank":       "   ",
+	})
+
+	type AppConfig struct {
+		Environment string `konfig:"app.environment" transform:"trim,lower"`
+		Endpoint    string `konfig:"app.endpoint" transform:"expand"`
+		Code        string `konfig:"app.code" transform:"upper, trim"`
+		Blank       string `konfig:"app.blank" transform:"trim"`
+		Untouched   string `konfig:"app.environment"`
+	}
+
+	var target AppConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, "prod", target.Environment)
+	assert.Equal(t, "https://eu-west-1.example.com", target.Endpoint)
+	assert.Equal(t, "AB", target.Code)
+	assert.Equal(t, "", target.Blank)
+	assert.Equal(t, "  PROD ", target.Untouched)
+
+	// Unknown names fail even when the key is absent
+	var invalid struct {
+		Name string `konfig:"app.missing" transform:"trim,reverse"`
+	}
+	err := cfg.Unmarshal(&invalid)
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Contains(t, err.Error(), "unknown transform: reverse")
+}
+
+func TestNewAPI_MarshalJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `
+server:
+  port: 8080
+  host: localhost
+  tls: true
+database:
+  url: postgres://db
+  password: hunter2
+ports: [80, 443]
+ratio: 0.5
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{"port": float64(8080), "host": "localhost", "tls": true}, decoded["server"])
+	assert.Equal(t, []interface{}{float64(80), float64(443)}, decoded["ports"])
+
+	// The JSON output loads back into the same values
+	jsonPath := filepath.Join(tempDir, "roundtrip.json")
+	require.NoError(t, os.WriteFile(jsonPath, data, 0644))
+	roundTrip, err := Load(jsonPath)
+	require.NoError(t, err)
+	for _, key := range cfg.Keys() {
+		assert.Equal(t, cfg.GetString(key), roundTrip.GetString(key), key)
+	}
+
+	// Secret keys and their subtrees are redacted in output only
+	cfg, err = Load(configPath, WithSecretKeys("database.password", "server.tls"))
+	require.NoError(t, err)
+	data, err = json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"password":"[REDACTED]"`)
+	assert.Contains(t, string(data), `"tls":"[REDACTED]"`)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.Equal(t, "hunter2", cfg.GetString("database.password"))
+
+	cfg, err = Load(configPath, WithSecretKeys("database"))
+	require.NoError(t, err)
+	data, err = json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "postgres://db")
+
+	// Keys colliding with a parent value are kept as dotted keys
+	cfg.Set("ports.1", 8443)
+	data, err = json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"ports.1":8443`)
+}
+
+func TestNewAPI_DefaultFuncTag(t *testing.T) {
+	RegisterDefaultFunc("test-region", func() string { return "eu-west-1" })
+	defer RegisterDefaultFunc("test-region", nil)
+
+	cfg := newConfig(map[string]interface{}{"app.name": "api"})
+
+	type AppConfig struct {
+		Name     string `konfig:"app.name" defaultFunc:"test-region"`
+		Region   string `konfig:"app.region" defaultFunc:"test-region"`
+		Zone     string `konfig:"app.zone" default:"a" defaultFunc:"test-region"`
+		Instance string `konfig:"app.instance" defaultFunc:"hostname"`
+		Token    string `konfig:"app.token" defaultFunc:"uuid"`
+	}
+
+	var target AppConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, "api", target.Name, "config value wins")
+	assert.Equal(t, "eu-west-1", target.Region)
+	assert.Equal(t, "a", target.Zone, "static default wins")
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	assert.Equal(t, hostname, target.Instance)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, target.Token)
+
+	var invalid struct {
+		Name string `konfig:"app.name" defaultFunc:"nope"`
+	}
+	err = cfg.Unmarshal(&invalid)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Contains(t, err.Error(), "unknown defaultFunc: nope")
+}
+
+func TestNewAPI_FromMap(t *testing.T) {
+	t.Setenv("KONFIG_TEST_DB_HOST", "db.test")
+
+	m := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls":  map[interface{}]interface{}{"enabled": true},
+		},
+		"database": map[string]interface{}{
+			"host": "${KONFIG_TEST_DB_HOST:localhost}",
+		},
+		"ports": []interface{}{80, 443},
+	}
+
+	cfg := FromMap(m)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	assert.True(t, cfg.GetBool("server.tls.enabled"))
+	assert.Equal(t, []int{80, 443}, cfg.GetIntSlice("ports"))
+	assert.Equal(t, "${KONFIG_TEST_DB_HOST:localhost}", cfg.GetString("database.host"), "no substitution by default")
+	assert.Empty(t, cfg.ReferencedEnvVars())
+
+	type ServerConfig struct {
+		Port int  `konfig:"server.port"`
+		TLS  bool `konfig:"server.tls.enabled"`
+	}
+	var target ServerConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, ServerConfig{Port: 8080, TLS: true}, target)
+
+	cfg = FromMap(m, WithEnvSubstitution(true))
+	assert.Equal(t, "db.test", cfg.GetString("database.host"))
+	assert.Equal(t, []string{"KONFIG_TEST_DB_HOST"}, cfg.ReferencedEnvVars())
+
+	// The source map is not retained
+	m["server"].(map[string]interface{})["port"] = 9090
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+}
+
+func TestNewAPI_KeyAlias(t *testing.T) {
+	RegisterKeyAlias("db.dsn", "database.url")
+	RegisterKeyAlias("legacy", "modern")
+	defer RegisterKeyAlias("db.dsn", "")
+	defer RegisterKeyAlias("legacy", "")
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+db:
+  dsn: postgres://old
+legacy:
+  timeout: 5s
+  retries: 3
+modern:
+  retries: 5
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	cfg, err := Load(configPath, WithLogger(logger))
+	require.NoError(t, err)
+
+	type AppConfig struct {
+		DatabaseURL string        `konfig:"database.url"`
+		Timeout     time.Duration `konfig:"modern.timeout"`
+		Retries     int           `konfig:"modern.retries"`
+	}
+	var target AppConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, "postgres://old", target.DatabaseURL)
+	assert.Equal(t, 5*time.Second, target.Timeout)
+	assert.Equal(t, 5, target.Retries, "an explicit new key wins")
+	assert.Equal(t, "postgres://old", cfg.GetString("db.dsn"), "old key is kept")
+
+	assert.Contains(t, logs.String(), "deprecated configuration key")
+	assert.Contains(t, logs.String(), "key=db.dsn replacement=database.url")
+	assert.Contains(t, logs.String(), "key=legacy replacement=modern")
+
+	// No warning when only the new key is used
+	logs.Reset()
+	require.NoError(t, os.WriteFile(configPath, []byte("database:\n  url: postgres://new\n"), 0644))
+	_, err = Load(configPath, WithLogger(logger))
+	require.NoError(t, err)
+	assert.Empty(t, logs.String())
+}
+
+func TestNewAPI_DurationSlice(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+retry:
+  backoffs: [1s, 5s, 30s, 1d]
+  schedule: "100ms, 2s"
+  broken: [1s, soon]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 24 * time.Hour}, cfg.GetDurationSlice("retry.backoffs"))
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 2 * time.Second}, cfg.GetDurationSlice("retry.schedule"))
+	assert.Nil(t, cfg.GetDurationSlice("retry.broken"))
+	assert.Nil(t, cfg.GetDurationSlice("retry.missing"))
+
+	type RetryConfig struct {
+		Backoffs []time.Duration `konfig:"retry.backoffs"`
+		Schedule []time.Duration `konfig:"retry.schedule"`
+		Fallback []time.Duration `konfig:"retry.fallback" default:"1s,2s"`
+	}
+	var target RetryConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 24 * time.Hour}, target.Backoffs)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 2 * time.Second}, target.Schedule)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, target.Fallback)
+
+	var broken struct {
+		Backoffs []time.Duration `konfig:"retry.broken"`
+	}
+	err = cfg.Unmarshal(&broken)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type_error")
+	assert.Contains(t, err.Error(), "element 1 'soon'")
+}
+
+func TestNewAPI_UnsupportedFieldType(t *testing.T) {
+	cfg := newConfig(map[string]interface{}{
+		"events":         "queue",
+		"handler":        "fn",
+		"server.options": "x",
+	})
+
+	type EventConfig struct {
+		Events chan string `konfig:"events"`
+	}
+	type HandlerConfig struct {
+		Handler func() `konfig:"handler"`
+	}
+	type ServerConfig struct {
+		Options []int `konfig:"options"`
+	}
+	type AppConfig struct {
+		Server ServerConfig `konfig:"server"`
+	}
+
+	tests := []struct {
+		name     string
+		target   interface{}
+		path     string
+		expected string
+	}{
+		{"chan", &EventConfig{}, "EventConfig.Events", "field of type chan string (kind chan) cannot be set from config key 'events'"},
+		{"func", &HandlerConfig{}, "HandlerConfig.Handler", "field of type func() (kind func) cannot be set from config key 'handler'"},
+		{"nested slice", &AppConfig{}, "ServerConfig.Options", "field of type []int (kind slice) cannot be set from config key 'server.options'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.Unmarshal(tt.target)
+			var configErr *ConfigError
+			require.ErrorAs(t, err, &configErr)
+			assert.Equal(t, "type_error", configErr.Type)
+			assert.Equal(t, tt.path, configErr.Path)
+			assert.Equal(t, tt.expected, configErr.Message)
+
+			var unsupported *unsupportedTypeError
+			assert.ErrorAs(t, err, &unsupported)
+		})
+	}
+}
+
+func TestNewAPI_GetSubConfigs(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+backends:
+  enabled: true
+  auth:
+    url: https://auth.internal
+    timeout: 5s
+    token: abc
+  billing:
+    url: https://billing.internal
+    retry:
+      attempts: 3
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath, WithSecretKeys("backends.auth.token"))
+	require.NoError(t, err)
+
+	subs := cfg.GetSubConfigs("backends")
+	require.Len(t, subs, 2, "scalar children are not namespaces")
+	assert.ElementsMatch(t, []string{"url", "timeout", "token"}, subs["auth"].Keys())
+	assert.Equal(t, "https://auth.internal", subs["auth"].GetString("url"))
+	assert.Equal(t, 5*time.Second, subs["auth"].GetDuration("timeout"))
+	assert.Equal(t, 3, subs["billing"].GetInt("retry.attempts"))
+
+	type Backend struct {
+		URL string `konfig:"url"`
+	}
+	var backend Backend
+	require.NoError(t, subs["billing"].Unmarshal(&backend))
+	assert.Equal(t, "https://billing.internal", backend.URL)
+
+	// Secret keys stay redacted relative to the scope
+	data, err := json.Marshal(subs["auth"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"token":"[REDACTED]"`)
+
+	assert.Empty(t, cfg.GetSubConfigs("missing"))
+}
+
+func TestNewAPI_GetStringMapInterface(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+plugin:
+  name: cache
+  enabled: true
+  size: 128
+  ratio: 0.75
+  tags: [fast, local]
+  backend:
+    host: localhost
+    pool:
+      max: 10
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	expected := map[string]interface{}{
+		"name":    "cache",
+		"enabled": true,
+		"size":    128,
+		"ratio":   0.75,
+		"tags":    []interface{}{"fast", "local"},
+		"backend": map[string]interface{}{
+			"host": "localhost",
+			"pool": map[string]interface{}{"max": 10},
+		},
+	}
+	assert.Equal(t, expected, cfg.GetStringMapInterface("plugin"))
+	assert.Equal(t, map[string]interface{}{"max": 10}, cfg.GetStringMapInterface("plugin.backend.pool"))
+	assert.Empty(t, cfg.GetStringMapInterface("missing"))
+}
+
+func TestNewAPI_WarnUnusedKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+server:
+  port: 8080
+  hots: typo
+  tls:
+    cert: /etc/cert.pem
+database:
+  url: postgres://db
+backoffs: [1s, 5s]
+legacy: true
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type TLSConfig struct {
+		Cert string `konfig:"cert"`
+	}
+	type ServerConfig struct {
+		Port int       `konfig:"port"`
+		TLS  TLSConfig `konfig:"tls"`
+	}
+	type AppConfig struct {
+		Server   ServerConfig `konfig:"server"`
+		Database struct {
+			URL string `konfig:"url"`
+		}
+		Backoffs []time.Duration `konfig:"backoffs"`
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	var target AppConfig
+	require.NoError(t, LoadInto(configPath, &target, WithWarnUnusedKeys(logger)))
+	assert.Equal(t, 8080, target.Server.Port)
+	assert.Equal(t, "/etc/cert.pem", target.Server.TLS.Cert)
+	assert.Equal(t, "postgres://db", target.Database.URL)
+
+	output := logs.String()
+	assert.Contains(t, output, "unused configuration key")
+	assert.Contains(t, output, "key=server.hots")
+	assert.Contains(t, output, "key=legacy")
+	assert.Equal(t, 2, strings.Count(output, "unused configuration key"), output)
+
+	// Without the option nothing is logged
+	logs.Reset()
+	require.NoError(t, LoadInto(configPath, &target, WithLogger(logger)))
+	assert.Empty(t, logs.String())
+}
+
+func TestNewAPI_Timestamps(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+release:
+  created: 2024-01-02
+  published: 2024-01-02T15:04:05Z
+  quoted: "2024-03-04"
+  broken: not-a-date
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	created := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	published := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	value, _ := cfg.Get("release.created")
+	assert.IsType(t, time.Time{}, value, "yaml.v3 decodes unquoted dates")
+	assert.Equal(t, "2024-01-02T00:00:00Z", cfg.GetString("release.created"))
+	assert.Equal(t, "2024-01-02T15:04:05Z", cfg.GetString("release.published"))
+	assert.Equal(t, "2024-03-04", cfg.GetString("release.quoted"))
+
+	assert.True(t, created.Equal(cfg.GetTime("release.created")))
+	assert.True(t, published.Equal(cfg.GetTime("release.published")))
+	assert.True(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC).Equal(cfg.GetTime("release.quoted")))
+	assert.True(t, cfg.GetTime("release.broken").IsZero())
+	assert.True(t, cfg.GetTime("release.missing").IsZero())
+
+	type ReleaseConfig struct {
+		Created   time.Time `konfig:"release.created"`
+		Published time.Time `konfig:"release.published"`
+		Quoted    time.Time `konfig:"release.quoted"`
+		Label     string    `konfig:"release.created"`
+		Fallback  time.Time `konfig:"release.missing" default:"2020-05-06"`
+	}
+	var target ReleaseConfig
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.True(t, created.Equal(target.Created))
+	assert.True(t, published.Equal(target.Published))
+	assert.Equal(t, 4, target.Quoted.Day())
+	assert.Equal(t, "2024-01-02T00:00:00Z", target.Label)
+	assert.Equal(t, time.May, target.Fallback.Month())
+
+	var broken struct {
+		At time.Time `konfig:"release.broken"`
+	}
+	err = cfg.Unmarshal(&broken)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot convert 'not-a-date' to time")
+}
+
+func TestNewAPI_TimeWithLayout(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+legacy:
+  start: 02/01/2006
+  holidays: [25/12/2024, 01/01/2025]
+  closures: "01/05/2025, 09/05/2025"
+  native: 2024-01-02
+  broken: 2024-13-45
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	start, err := cfg.GetTimeWithLayout("legacy.start", "02/01/2006")
+	require.NoError(t, err)
+	assert.True(t, time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC).Equal(start))
+
+	native, err := cfg.GetTimeWithLayout("legacy.native", "02/01/2006")
+	require.NoError(t, err)
+	assert.Equal(t, 2024, native.Year())
+
+	missing, err := cfg.GetTimeWithLayout("legacy.missing", "02/01/2006")
+	require.NoError(t, err)
+	assert.True(t, missing.IsZero())
+
+	_, err = cfg.GetTimeWithLayout("legacy.broken", "02/01/2006")
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "type_error", configErr.Type)
+	assert.Contains(t, err.Error(), "'2024-13-45'")
+	assert.Contains(t, err.Error(), "'02/01/2006'")
+
+	type Calendar struct {
+		Start    time.Time   `konfig:"legacy.start" layout:"02/01/2006"`
+		Holidays []time.Time `konfig:"legacy.holidays" layout:"02/01/2006"`
+		Closures []time.Time `konfig:"legacy.closures" layout:"02/01/2006"`
+	}
+	var target Calendar
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, time.January, target.Start.Month())
+	require.Len(t, target.Holidays, 2)
+	assert.Equal(t, time.December, target.Holidays[0].Month())
+	assert.Equal(t, 2025, target.Holidays[1].Year())
+	require.Len(t, target.Closures, 2)
+	assert.Equal(t, 9, target.Closures[1].Day())
+
+	var broken struct {
+		Days []time.Time `konfig:"legacy.holidays" layout:"2006-01-02"`
+	}
+	err = cfg.Unmarshal(&broken)
+	require.Error(t, err)
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "type_error", configErr.Type)
+	assert.Contains(t, err.Error(), "cannot convert '25/12/2024' to time with layout '2006-01-02'")
+}
+
+func TestNewAPI_GetMany(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+			"name": "app",
+		},
+	})
+
+	values := cfg.GetMany("db.host", "db.port", "db.user")
+	assert.Equal(t, map[string]string{
+		"db.host": "localhost",
+		"db.port": "5432",
+		"db.user": "",
+	}, values)
+
+	values, err := cfg.GetManyE("db.host", "db.port", "db.name")
+	require.NoError(t, err)
+	assert.Equal(t, "app", values["db.name"])
+
+	_, err = cfg.GetManyE("db.host", "db.user", "db.password")
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Equal(t, "db.user, db.password", configErr.Path)
+
+	assert.Empty(t, cfg.GetMany())
+}
+
+func TestNewAPI_ImplicitFieldNames(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+database:
+  host: db.internal
+  max_conns: 20
+  httpport: 8081
+  timeout: 5s
+  tagged: yes-tagged
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type Database struct {
+		Host     string
+		MaxConns int
+		HTTPPort int
+		Timeout  time.Duration
+		Region   string `default:"eu-west-1"`
+		Name     string `konfig:"tagged"`
+	}
+	type AppConfig struct {
+		Database Database
+	}
+
+	var plain AppConfig
+	require.NoError(t, LoadInto(configPath, &plain))
+	assert.Empty(t, plain.Database.Host, "untagged fields stay unmapped by default")
+	assert.Equal(t, "yes-tagged", plain.Database.Name)
+
+	var implicit AppConfig
+	require.NoError(t, LoadInto(configPath, &implicit, WithImplicitFieldNames()))
+	assert.Equal(t, "db.internal", implicit.Database.Host)
+	assert.Equal(t, 20, implicit.Database.MaxConns)
+	assert.Equal(t, 8081, implicit.Database.HTTPPort, "lowercased name used when only it is set")
+	assert.Equal(t, 5*time.Second, implicit.Database.Timeout)
+	assert.Equal(t, "eu-west-1", implicit.Database.Region)
+	assert.Equal(t, "yes-tagged", implicit.Database.Name)
+
+	assert.Equal(t, "http_port", snakeCase("HTTPPort"))
+	assert.Equal(t, "max_conns", snakeCase("MaxConns"))
+	assert.Equal(t, "user_id", snakeCase("UserID"))
+}
+
+func TestNewAPI_ConflictReport(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte("server:\n  port: 8080\n  host: localhost\nlog: info\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("server:\n  port: 80\nmetrics: true\n"), 0644))
+	overridesPath := filepath.Join(tempDir, "overrides.yaml")
+	require.NoError(t, os.WriteFile(overridesPath, []byte("log: debug\nmetrics: false\n"), 0644))
+
+	cfg, err := LoadWithProfileAndOverrides(basePath, "prod", overridesPath, WithConflictReport())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log", "metrics", "server.port"}, cfg.Conflicts())
+	assert.Equal(t, "debug", cfg.GetString("log"))
+
+	quiet, err := LoadWithProfileAndOverrides(basePath, "prod", overridesPath)
+	require.NoError(t, err)
+	assert.Nil(t, quiet.Conflicts())
+
+	single, err := LoadWithProfile(basePath, "staging", WithConflictReport())
+	require.NoError(t, err)
+	assert.Empty(t, single.Conflicts())
+}
+
+func TestNewAPI_StringSliceFields(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+hosts:
+  list: [" a ", b, "", c]
+  comma: "a, b,,c"
+  lines: |
+    a
+      b
+
+    c
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type Label string
+	type HostsConfig struct {
+		List     []string        `konfig:"hosts.list"`
+		Comma    []string        `konfig:"hosts.comma"`
+		Lines    []string        `konfig:"hosts.lines"`
+		Default  []string        `konfig:"hosts.missing" default:"a,b,c"`
+		Named    []Label         `konfig:"hosts.comma"`
+		Timeouts []time.Duration `konfig:"hosts.missing" default:"1s\n2s"`
+	}
+	var target HostsConfig
+	require.NoError(t, LoadInto(configPath, &target))
+
+	expected := []string{"a", "b", "c"}
+	assert.Equal(t, expected, target.List)
+	assert.Equal(t, expected, target.Comma)
+	assert.Equal(t, expected, target.Lines)
+	assert.Equal(t, expected, target.Default)
+	assert.Equal(t, []Label{"a", "b", "c"}, target.Named)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, target.Timeouts)
+}
+
+func TestNewAPI_GetIntTruncatesFloats(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"int":      30,
+		"whole":    30.0,
+		"fraction": 30.9,
+		"negative": -2.5,
+		"quoted":   "30.9",
+		"huge":     1e30,
+		"text":     "thirty",
+	})
+
+	assert.Equal(t, 30, cfg.GetInt("int"))
+	assert.Equal(t, 30, cfg.GetInt("whole"))
+	assert.Equal(t, 30, cfg.GetInt("fraction"))
+	assert.Equal(t, -2, cfg.GetInt("negative"))
+	assert.Equal(t, 30, cfg.GetInt("quoted"))
+	assert.Equal(t, 0, cfg.GetInt("huge"))
+	assert.Equal(t, 0, cfg.GetInt("text"))
+}
+
+func TestNewAPI_SubUnmarshal(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+server:
+  port: 8080
+database:
+  host: db.internal
+  port: 5432
+  password: hunter2
+  pool:
+    size: 10
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath, WithSecretKeys("database.password"))
+	require.NoError(t, err)
+
+	type PoolConfig struct {
+		Size int `konfig:"size"`
+	}
+	type DatabaseConfig struct {
+		Host     string     `konfig:"host"`
+		Port     int        `konfig:"port"`
+		User     string     `konfig:"user" default:"app"`
+		Password string     `konfig:"password"`
+		Pool     PoolConfig `konfig:"pool"`
+	}
+
+	db := cfg.Sub("database")
+	var dbCfg DatabaseConfig
+	require.NoError(t, db.Unmarshal(&dbCfg))
+	assert.Equal(t, DatabaseConfig{
+		Host:     "db.internal",
+		Port:     5432,
+		User:     "app",
+		Password: "hunter2",
+		Pool:     PoolConfig{Size: 10},
+	}, dbCfg)
+
+	assert.Empty(t, db.GetString("server.port"))
+	assert.Equal(t, 10, cfg.Sub("database.pool").GetInt("size"))
+	assert.Empty(t, cfg.Sub("missing").Keys())
+	assert.ElementsMatch(t, cfg.Keys(), cfg.Sub("").Keys())
+
+	data, err := db.MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2", "secret keys stay redacted in the subtree")
+
+	cfg.Set("database.host", "changed")
+	assert.Equal(t, "db.internal", db.GetString("host"), "Sub is a detached snapshot")
+}
+
+func TestNewAPI_UintOverflow(t *testing.T) {
+	type Widths struct {
+		U8  uint8  `konfig:"u8"`
+		U16 uint16 `konfig:"u16"`
+		U32 uint32 `konfig:"u32"`
+		U64 uint64 `konfig:"u64"`
+	}
+
+	var limits Widths
+	require.NoError(t, FromMap(map[string]interface{}{
+		"u8":  255,
+		"u16": 65535,
+		"u32": 4294967295,
+		"u64": "18446744073709551615",
+	}).Unmarshal(&limits))
+	assert.Equal(t, Widths{U8: math.MaxUint8, U16: math.MaxUint16, U32: math.MaxUint32, U64: math.MaxUint64}, limits)
+
+	tests := []struct {
+		key     string
+		value   interface{}
+		field   string
+		message string
+	}{
+		{"u8", 256, "Widths.U8", "value '256' overflows uint8 (max 255)"},
+		{"u16", 70000, "Widths.U16", "value '70000' overflows uint16 (max 65535)"},
+		{"u32", 4294967296, "Widths.U32", "value '4294967296' overflows uint32 (max 4294967295)"},
+		{"u64", "18446744073709551616", "Widths.U64", "overflows uint64 (max 18446744073709551615)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			var target Widths
+			err := FromMap(map[string]interface{}{tt.key: tt.value}).Unmarshal(&target)
+			require.Error(t, err)
+
+			var configErr *ConfigError
+			require.ErrorAs(t, err, &configErr)
+			assert.Equal(t, "type_error", configErr.Type)
+			assert.Equal(t, tt.field, configErr.Path)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestNewAPI_IntOverflow(t *testing.T) {
+	type Widths struct {
+		I8    int8  `konfig:"i8"`
+		I16   int16 `konfig:"i16"`
+		I32   int32 `konfig:"i32"`
+		I64   int64 `konfig:"i64"`
+		Count int16 `konfig:"count" format:"count"`
+	}
+
+	var limits Widths
+	require.NoError(t, FromMap(map[string]interface{}{
+		"i8":    -128,
+		"i16":   32767,
+		"i32":   -2147483648,
+		"i64":   "9223372036854775807",
+		"count": "32k",
+	}).Unmarshal(&limits))
+	assert.Equal(t, Widths{I8: math.MinInt8, I16: math.MaxInt16, I32: math.MinInt32, I64: math.MaxInt64, Count: 32000}, limits)
+
+	tests := []struct {
+		key     string
+		value   interface{}
+		field   string
+		message string
+	}{
+		{"i8", 128, "Widths.I8", "value '128' overflows int8 (min -128, max 127)"},
+		{"i8", -129, "Widths.I8", "value '-129' overflows int8"},
+		{"i16", 40000, "Widths.I16", "value '40000' overflows int16 (min -32768, max 32767)"},
+		{"i32", 2147483648, "Widths.I32", "value '2147483648' overflows int32"},
+		{"i64", "9223372036854775808", "Widths.I64", "overflows int64 (min -9223372036854775808, max 9223372036854775807)"},
+		{"count", "40k", "Widths.Count", "value 40000 overflows int16"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s=%v", tt.key, tt.value), func(t *testing.T) {
+			var target Widths
+			err := FromMap(map[string]interface{}{tt.key: tt.value}).Unmarshal(&target)
+			require.Error(t, err)
+
+			var configErr *ConfigError
+			require.ErrorAs(t, err, &configErr)
+			assert.Equal(t, "type_error", configErr.Type)
+			assert.Equal(t, tt.field, configErr.Path)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestNewAPI_Provenance(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	profilePath := filepath.Join(tempDir, "app-prod.yaml")
+	overridesPath := filepath.Join(tempDir, "overrides.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte(`
+server:
+  host: localhost
+  port: 8080
+database:
+  password: ${KONFIG_PROVENANCE_PASSWORD:secret}
+log: info
+`), 0644))
+	require.NoError(t, os.WriteFile(profilePath, []byte("server:\n  port: 80\n"), 0644))
+	require.NoError(t, os.WriteFile(overridesPath, []byte("log: debug\n"), 0644))
+
+	cfg, err := LoadWithProfileAndOverrides(basePath, "prod", overridesPath, WithProvenance())
+	require.NoError(t, err)
+
+	assert.Equal(t, basePath, cfg.Source("server.host"))
+	assert.Equal(t, profilePath, cfg.Source("server.port"))
+	assert.Equal(t, overridesPath, cfg.Source("log"))
+	assert.Equal(t, "${KONFIG_PROVENANCE_PASSWORD} in "+basePath, cfg.Source("database.password"))
+	assert.Empty(t, cfg.Source("missing"))
+
+	cfg.Set("server.host", "example.com")
+	assert.Equal(t, "Set", cfg.Source("server.host"))
+	assert.Equal(t, profilePath, cfg.Sub("server").Source("port"))
+
+	// Reload re-reads the files and their provenance
+	require.NoError(t, os.WriteFile(profilePath, []byte("log: warn\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, basePath, cfg.Source("server.port"))
+	assert.Equal(t, overridesPath, cfg.Source("log"))
+
+	plain, err := LoadWithProfile(basePath, "prod")
+	require.NoError(t, err)
+	assert.Empty(t, plain.Source("server.port"), "provenance is opt-in")
+}
+
+func TestNewAPI_LoadWithSchema(t *testing.T) {
+	tempDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	v2 := write("v2.yaml", "konfig:\n  version: v2\nserver:\n  port: 8080\n")
+	api := write("api.yaml", "apiVersion: v1\n")
+	v3 := write("v3.yaml", "konfig:\n  version: v3\n")
+	unversioned := write("plain.yaml", "server:\n  port: 8080\n")
+
+	cfg, err := LoadWithSchema(v2, []string{"v1", "v2"})
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+
+	_, err = LoadWithSchema(api, []string{"v1", "v2"})
+	require.NoError(t, err)
+
+	_, err = LoadWithSchema(v3, []string{"v1", "v2"})
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Equal(t, v3, configErr.Path)
+	assert.Contains(t, err.Error(), "unsupported schema version 'v3' (supported: v1, v2)")
+
+	_, err = LoadWithSchema(unversioned, []string{"v1"})
+	require.NoError(t, err, "unversioned files are accepted")
+
+	_, err = LoadWithSchema(unversioned, []string{"v1"}, WithStrictSchema())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing schema version")
+
+	_, err = LoadWithSchema(api, []string{"v1"}, WithStrictSchema())
+	require.NoError(t, err)
+
+	// Options combine as for Load
+	slashed, err := LoadWithSchema(v2, []string{"v2"}, WithKeyDelimiter("/"), WithStrictSchema(), WithOverride("server/port", 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, slashed.GetInt("server/port"))
+
+	// Reload refuses a file rewritten for an unsupported schema
+	require.NoError(t, os.WriteFile(v2, []byte("konfig:\n  version: v9\nserver:\n  port: 9090\n"), 0644))
+	require.Error(t, cfg.Reload())
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+}
+
+func TestNewAPI_GetBoolNumeric(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+one: 1
+zero: 0
+two: 2
+float_one: 1.0
+float_zero: 0.0
+negative: -1
+native: true
+quoted_two: "2"
+quoted_one: "1"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	for key, expected := range map[string]bool{
+		"one":        true,
+		"zero":       false,
+		"two":        true,
+		"float_one":  true,
+		"float_zero": false,
+		"negative":   true,
+		"native":     true,
+		"quoted_one": true,
+	} {
+		b, err := cfg.GetBoolE(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, expected, b, key)
+		assert.Equal(t, expected, cfg.GetBool(key), key)
+	}
+
+	_, err = cfg.GetBoolE("quoted_two")
+	require.Error(t, err, "strings keep strict parsing")
+	assert.False(t, cfg.GetBool("quoted_two"))
+}
+
+func TestNewAPI_ListIntoScalarField(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("port: [8080]\nhosts: [a, b]\n"), 0644))
+
+	type ServerConfig struct {
+		Port int `konfig:"port"`
+	}
+	var target ServerConfig
+	err := LoadInto(configPath, &target)
+	require.Error(t, err)
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "type_error", configErr.Type)
+	assert.Equal(t, "ServerConfig.Port", configErr.Path)
+	assert.Contains(t, err.Error(), "config key 'port' is a list but field Port is int")
+	assert.NotContains(t, err.Error(), "cannot convert '[8080]' to int", "previously surfaced as a failed Atoi")
+
+	type HostsConfig struct {
+		Hosts []string `konfig:"hosts"`
+		Host  string   `konfig:"hosts"`
+	}
+	var hosts HostsConfig
+	err = LoadInto(configPath, &hosts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config key 'hosts' is a list but field Host is string")
+
+	// A set env tag variable wins, so the config value's shape does not matter
+	t.Setenv("KONFIG_TEST_PORT", "9090")
+	var fromEnv struct {
+		Port int `konfig:"port" env:"KONFIG_TEST_PORT"`
+	}
+	require.NoError(t, LoadInto(configPath, &fromEnv))
+	assert.Equal(t, 9090, fromEnv.Port)
+	var typed struct {
+		Port int `konfig:"port" env:"KONFIG_TEST_PORT"`
+	}
+	require.NoError(t, LoadIntoTyped(configPath, &typed))
+	assert.Equal(t, 9090, typed.Port)
+}
+
+func TestNewAPI_GenerateSample(t *testing.T) {
+	type DatabaseConfig struct {
+		Host     string `konfig:"host" default:"localhost"`
+		Port     int    `konfig:"port" default:"5432"`
+		Password string `konfig:"password" env:"KONFIG_SAMPLE_DB_PASSWORD" secret:"true"`
+		Token    string `konfig:"token" secret:"true"`
+	}
+	type AppConfig struct {
+		Name     string         `konfig:"app.name" default:"8080"`
+		Timeout  time.Duration  `konfig:"server.timeout" default:"30s"`
+		Port     int            `konfig:"server.port"`
+		ID       string         `konfig:"id" defaultFunc:"hostname"`
+		Database DatabaseConfig `konfig:"database"`
+		Cache    struct {
+			TTL int `konfig:"ttl" default:"60"`
+		}
+		internal string `konfig:"internal"`
+		Ignored  string
+	}
+
+	sample, err := GenerateSample(&AppConfig{})
+	require.NoError(t, err)
+	text := string(sample)
+
+	assert.Contains(t, text, "app:\n  # string\n  name: \"8080\"\n")
+	assert.Contains(t, text, "server:\n  # time.Duration\n  timeout: 30s\n  # int\n  port:\n")
+	assert.Contains(t, text, "# string, default from hostname\nid:\n")
+	assert.Contains(t, text, "# string, env KONFIG_SAMPLE_DB_PASSWORD\n  password: ${KONFIG_SAMPLE_DB_PASSWORD}\n")
+	assert.Contains(t, text, "token: CHANGE_ME\n")
+	assert.Contains(t, text, "cache:\n  # int\n  ttl: 60\n")
+	assert.NotContains(t, text, "internal")
+	assert.NotContains(t, text, "ignored")
+
+	// The sample loads back into the same defaults
+	samplePath := filepath.Join(t.TempDir(), "sample.yaml")
+	require.NoError(t, os.WriteFile(samplePath, sample, 0644))
+	var fromSample, fromDefaults AppConfig
+	require.NoError(t, LoadInto(samplePath, &fromSample))
+	require.NoError(t, FromMap(map[string]interface{}{}).Unmarshal(&fromDefaults))
+	fromDefaults.Database.Token = "CHANGE_ME"
+	assert.Equal(t, fromDefaults, fromSample)
+
+	t.Run("file defaults round-trip", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		require.NoError(t, os.WriteFile("db_pw", []byte("s3cret\n"), 0600))
+
+		type SecretConfig struct {
+			Password string `konfig:"db.password" default:"${file:db_pw}"`
+		}
+		sample, err := GenerateSample(SecretConfig{})
+		require.NoError(t, err)
+		assert.Contains(t, string(sample), "# string, default ${file:db_pw}\n  password:\n")
+
+		require.NoError(t, os.WriteFile("sample.yaml", sample, 0644))
+		var target SecretConfig
+		require.NoError(t, LoadInto("sample.yaml", &target))
+		assert.Equal(t, "s3cret", target.Password)
+	})
+
+	_, err = GenerateSample("not a struct")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+}
+
+func TestNewAPI_SecretFileDefaults(t *testing.T) {
+	secretsDir := t.TempDir()
+	passwordPath := filepath.Join(secretsDir, "db_pw")
+	require.NoError(t, os.WriteFile(passwordPath, []byte("s3cret\n"), 0600))
+	// Tags are constants, so the test refers to the secret files relatively
+	t.Chdir(secretsDir)
+
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("database:\n  host: db.internal\n  user: app\n"), 0644))
+
+	type DatabaseConfig struct {
+		Host     string `konfig:"database.host" required:"true"`
+		Password string `konfig:"database.password" default:"${file:db_pw}" required:"true"`
+		DSN      string `konfig:"database.dsn" default:"user=app password=${file:db_pw}"`
+		Replica  string `konfig:"database.replica_password" default:"${file:missing}"`
+		User     string `konfig:"database.user" default:"${file:missing}" required:"true"`
+	}
+	var target DatabaseConfig
+	require.NoError(t, LoadInto(configPath, &target))
+	assert.Equal(t, "s3cret", target.Password)
+	assert.Equal(t, "user=app password=s3cret", target.DSN)
+	assert.Empty(t, target.Replica, "an optional field with a missing secret file stays empty")
+	assert.Equal(t, "app", target.User, "config values win over the secret file")
+
+	type RequiredSecret struct {
+		Token string `konfig:"api.token" default:"${file:missing}" required:"true"`
+	}
+	var secret RequiredSecret
+	err := LoadInto(configPath, &secret)
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Equal(t, "RequiredSecret.Token", configErr.Path)
+	assert.Contains(t, err.Error(), "cannot read secret file missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	type RequiredKey struct {
+		Port int `konfig:"server.port" required:"true"`
+	}
+	var key RequiredKey
+	err = LoadInto(configPath, &key)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "required config key 'server.port' is not set")
+}
+
+func TestNewAPI_GetFloat64E(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"rate":      0.25,
+		"integer":   3,
+		"dotted":    "3.14",
+		"comma":     "3,14",
+		"thousands": "1,000,000",
+		"text":      "fast",
+		"empty":     "",
+	})
+
+	for key, expected := range map[string]float64{"rate": 0.25, "integer": 3, "dotted": 3.14} {
+		f, err := cfg.GetFloat64E(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, expected, f, key)
+	}
+
+	f, err := cfg.GetFloat64E("missing")
+	require.NoError(t, err)
+	assert.Zero(t, f)
+
+	_, err = cfg.GetFloat64E("comma")
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "type_error", configErr.Type)
+	assert.Equal(t, "comma", configErr.Path)
+	assert.Contains(t, err.Error(), "write decimals with a dot, e.g. '3.14'")
+	assert.Zero(t, cfg.GetFloat64("comma"))
+
+	_, err = cfg.GetFloat64E("thousands")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not decimal or thousands separators")
+
+	for _, key := range []string{"text", "empty"} {
+		_, err = cfg.GetFloat64E(key)
+		require.Error(t, err, key)
+		assert.Contains(t, err.Error(), "value is not a number")
+	}
+}
+
+func TestNewAPI_HasPrefix(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "localhost",
+			"pool": map[string]interface{}{"size": 10},
+		},
+		"databases": "legacy",
+		"name":      "app",
+	})
+
+	assert.True(t, cfg.HasPrefix("database"))
+	assert.True(t, cfg.HasPrefix("database.pool"))
+	assert.False(t, cfg.HasPrefix("database.host"), "a scalar key is not a section")
+	assert.False(t, cfg.HasPrefix("data"), "prefixes match whole segments")
+	assert.False(t, cfg.HasPrefix("name"))
+	assert.False(t, cfg.HasPrefix("cache"))
+
+	cfg.Set("cache.ttl", 60)
+	assert.True(t, cfg.HasPrefix("cache"))
+
+	slashed := FromMap(map[string]interface{}{
+		"hosts": map[string]interface{}{"app.example.com": map[string]interface{}{"port": 443}},
+	}, WithKeyDelimiter("/"))
+	assert.True(t, slashed.HasPrefix("hosts/app.example.com"))
+	assert.False(t, slashed.HasPrefix("hosts/app"))
+}
+
+func TestNewAPI_LoadAt(t *testing.T) {
+	tempDir := t.TempDir()
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+	valuesContent := `
+replicaCount: 2
+myapp:
+  server:
+    port: 8080
+  password: ${KONFIG_LOADAT_PASSWORD:changeme}
+other:
+  server:
+    port: 9090
+`
+	require.NoError(t, os.WriteFile(valuesPath, []byte(valuesContent), 0644))
+
+	cfg, err := LoadAt(valuesPath, "myapp", WithSecretKeys("myapp.password"))
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	assert.Equal(t, "changeme", cfg.GetString("password"))
+	assert.ElementsMatch(t, []string{"server.port", "password"}, cfg.Keys())
+
+	data, err := cfg.MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "changeme")
+
+	nested, err := LoadAt(valuesPath, "other.server")
+	require.NoError(t, err)
+	assert.Equal(t, 9090, nested.GetInt("port"))
+
+	_, err = LoadAt(valuesPath, "missing")
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "file_not_found", configErr.Type)
+	assert.Equal(t, valuesPath+"#missing", configErr.Path)
+
+	_, err = LoadAt(valuesPath, "replicaCount")
+	require.Error(t, err, "a scalar is not a section")
+
+	require.NoError(t, os.WriteFile(valuesPath, []byte("myapp:\n  server:\n    port: 8081\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 8081, cfg.GetInt("server.port"))
+}
+
+func TestNewAPI_YAML11Bools(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+feature:
+  enabled: no
+  debug: Yes
+  metrics: ON
+  quoted: "off"
+  native: true
+  name: norway
+  flags: [y, n, maybe]
+on:
+  push: main
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	// yaml.v3 follows YAML 1.2: the YAML 1.1 spellings stay strings
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	value, _ := cfg.Get("feature.enabled")
+	assert.Equal(t, "no", value)
+	value, _ = cfg.Get("feature.native")
+	assert.Equal(t, true, value)
+
+	cfg, err = Load(configPath, WithYAML11Bools())
+	require.NoError(t, err)
+	for key, expected := range map[string]interface{}{
+		"feature.enabled": false,
+		"feature.debug":   true,
+		"feature.metrics": true,
+		"feature.quoted":  "off",
+		"feature.native":  true,
+		"feature.name":    "norway",
+		"on.push":         "main",
+	} {
+		value, exists := cfg.Get(key)
+		require.True(t, exists, key)
+		assert.Equal(t, expected, value, key)
+	}
+	value, _ = cfg.Get("feature.flags")
+	assert.Equal(t, []interface{}{true, false, "maybe"}, value)
+
+	type FeatureConfig struct {
+		Enabled bool `konfig:"feature.enabled"`
+		Debug   bool `konfig:"feature.debug"`
+	}
+	var target FeatureConfig
+	require.NoError(t, LoadInto(configPath, &target, WithYAML11Bools()))
+	assert.Equal(t, FeatureConfig{Enabled: false, Debug: true}, target)
+}
+
+func TestNewAPI_GetStringMapDepth(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"backends": map[string]interface{}{
+			"timeout": "5s",
+			"auth": map[string]interface{}{
+				"host": "auth.internal",
+				"tls":  map[string]interface{}{"enabled": true},
+			},
+			"ports": []interface{}{80, 443},
+		},
+	})
+
+	assert.Equal(t, map[string]string{
+		"timeout":          "5s",
+		"auth.host":        "auth.internal",
+		"auth.tls.enabled": "true",
+		"ports":            "[80 443]",
+	}, cfg.GetStringMap("backends"))
+
+	assert.Equal(t, map[string]string{
+		"timeout": "5s",
+		"ports":   "[80 443]",
+	}, cfg.GetStringMapDepth("backends", 1, false))
+
+	assert.Equal(t, map[string]string{
+		"timeout":   "5s",
+		"ports":     "[80 443]",
+		"auth.host": "auth.internal",
+	}, cfg.GetStringMapDepth("backends", 2, false))
+
+	collapsed := cfg.GetStringMapDepth("backends", 1, true)
+	assert.Equal(t, "5s", collapsed["timeout"])
+	assert.JSONEq(t, `{"host": "auth.internal", "tls": {"enabled": true}}`, collapsed["auth"])
+
+	collapsed = cfg.GetStringMapDepth("backends", 2, true)
+	assert.Equal(t, "auth.internal", collapsed["auth.host"])
+	assert.JSONEq(t, `{"enabled": true}`, collapsed["auth.tls"])
+
+	assert.Equal(t, cfg.GetStringMap("backends"), cfg.GetStringMapDepth("backends", 0, false))
+	assert.Empty(t, cfg.GetStringMapDepth("missing", 1, true))
+}
+
+func TestNewAPI_LogRotationFormats(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+logging:
+  level: info
+  max_size: 100MiB
+  max_backups: 3
+  max_age: 28d
+  buffer: 1.5 kb
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	// Mirrors LoggingConfig in examples/production with lumberjack-style fields
+	type LoggingConfig struct {
+		Level      string        `konfig:"level"`
+		MaxSize    int64         `konfig:"max_size" format:"bytes"`
+		MaxBackups int           `konfig:"max_backups" format:"count"`
+		MaxAge     time.Duration `konfig:"max_age"`
+		Buffer     int           `konfig:"buffer" format:"bytes"`
+		Archive    uint64        `konfig:"archive" format:"bytes" default:"2TB"`
+	}
+	type AppConfig struct {
+		Logging LoggingConfig `konfig:"logging"`
+	}
+
+	var target AppConfig
+	require.NoError(t, LoadInto(configPath, &target))
+	assert.Equal(t, LoggingConfig{
+		Level:      "info",
+		MaxSize:    100 << 20,
+		MaxBackups: 3,
+		MaxAge:     28 * 24 * time.Hour,
+		Buffer:     1500,
+		Archive:    2e12,
+	}, target.Logging)
+
+	for value, expected := range map[string]int64{"512": 512, "1b": 1, "10KB": 10000, "1GiB": 1 << 30, "0.5 MiB": 1 << 19} {
+		n, err := parseBytes(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, n, value)
+	}
+
+	for _, value := range []string{"", "MB", "10 parsecs", "-1MB", "1e30TB"} {
+		_, err := parseBytes(value)
+		assert.Error(t, err, value)
+	}
+
+	var small struct {
+		Size int16 `konfig:"logging.max_size" format:"bytes"`
+	}
+	err := LoadInto(configPath, &small)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "overflows int16")
+}
+
+func TestNewAPI_Int64Fields(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+storage:
+  quota: 5000000000
+  id: 9223372036854775807
+  min: -9223372036854775808
+  quoted: "4294967296"
+  ratio: 0.75
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type StorageConfig struct {
+		Quota    int64   `konfig:"storage.quota"`
+		ID       int64   `konfig:"storage.id"`
+		Min      int64   `konfig:"storage.min"`
+		Quoted   int64   `konfig:"storage.quoted"`
+		Fallback int64   `konfig:"storage.missing" default:"3000000000"`
+		Ratio    float64 `konfig:"storage.ratio"`
+	}
+	var target StorageConfig
+	require.NoError(t, LoadInto(configPath, &target))
+	assert.Equal(t, StorageConfig{
+		Quota:    5_000_000_000,
+		ID:       math.MaxInt64,
+		Min:      math.MinInt64,
+		Quoted:   1 << 32,
+		Fallback: 3_000_000_000,
+		Ratio:    0.75,
+	}, target)
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5_000_000_000), cfg.GetInt64("storage.quota"))
+	assert.Equal(t, int64(math.MaxInt64), cfg.GetInt64("storage.id"))
+	assert.Equal(t, int64(1<<32), cfg.GetInt64("storage.quoted"))
+	assert.Equal(t, int64(0), cfg.GetInt64("storage.ratio"))
+	assert.Equal(t, int64(0), cfg.GetInt64("storage.missing"))
+}
+
+func TestNewAPI_RequiredEnvFields(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("database:\n  pool: 5\n"), 0644))
+
+	type DatabaseConfig struct {
+		URL  string `konfig:"database.url" env:"KONFIG_TEST_DATABASE_URL" required:"true"`
+		Pool int    `konfig:"database.pool" env:"KONFIG_TEST_DATABASE_POOL" required:"true"`
+	}
+
+	var target DatabaseConfig
+	err := LoadInto(configPath, &target)
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Equal(t, "DatabaseConfig.URL", configErr.Path)
+	assert.Contains(t, err.Error(), "required config key 'database.url' is not set and environment variable KONFIG_TEST_DATABASE_URL is empty")
+
+	t.Setenv("KONFIG_TEST_DATABASE_URL", "postgres://db.internal/app")
+	require.NoError(t, LoadInto(configPath, &target))
+	assert.Equal(t, "postgres://db.internal/app", target.URL)
+	assert.Equal(t, 5, target.Pool, "the config key satisfies required without the env var")
+
+	type WithDefault struct {
+		Region string `konfig:"region" env:"KONFIG_TEST_REGION" default:"eu-west-1" required:"true"`
+	}
+	var withDefault WithDefault
+	require.NoError(t, LoadInto(configPath, &withDefault))
+	assert.Equal(t, "eu-west-1", withDefault.Region)
+}
+
+func TestNewAPI_JSONFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+routes: '[{"path": "/", "backend": "web"}, {"path": "/api", "backend": "api", "timeout": 5}]'
+labels: '{"team": "core", "tier": "1"}'
+limits: '{"rps": 100, "burst": 20}'
+broken: '[{"path": "/"'
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type Route struct {
+		Path    string `json:"path"`
+		Backend string `json:"backend"`
+		Timeout int    `json:"timeout"`
+	}
+	type Limits struct {
+		RPS   int `json:"rps"`
+		Burst int `json:"burst"`
+	}
+	type RouterConfig struct {
+		Routes []Route           `konfig:"routes" format:"json"`
+		Labels map[string]string `konfig:"labels" format:"json"`
+		Limits Limits            `konfig:"limits" format:"json"`
+		Extra  map[string]int    `konfig:"extra" format:"json" default:"{\"retries\": 3}"`
+	}
+
+	var target RouterConfig
+	require.NoError(t, LoadInto(configPath, &target))
+	assert.Equal(t, []Route{{Path: "/", Backend: "web"}, {Path: "/api", Backend: "api", Timeout: 5}}, target.Routes)
+	assert.Equal(t, map[string]string{"team": "core", "tier": "1"}, target.Labels)
+	assert.Equal(t, Limits{RPS: 100, Burst: 20}, target.Limits)
+	assert.Equal(t, map[string]int{"retries": 3}, target.Extra)
+
+	type BrokenConfig struct {
+		Routes []Route `konfig:"broken" format:"json"`
+	}
+	var broken BrokenConfig
+	err := LoadInto(configPath, &broken)
+	require.Error(t, err)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "type_error", configErr.Type)
+	assert.Equal(t, "BrokenConfig.Routes", configErr.Path)
+	assert.Contains(t, err.Error(), "cannot decode value as JSON: unexpected end of JSON input")
+}
+
+func TestNewAPI_DurationClamped(t *testing.T) {
+	handler := &recordingHandler{}
+	cfg := FromMap(map[string]interface{}{
+		"timeouts": map[string]interface{}{
+			"zero":  "0s",
+			"huge":  "999h",
+			"ok":    "30s",
+			"bogus": "soon",
+		},
+	}, WithLogger(slog.New(handler)))
+
+	const minTimeout, maxTimeout, def = time.Second, time.Hour, 10 * time.Second
+	tests := []struct {
+		name     string
+		key      string
+		want     time.Duration
+		wantWarn string
+	}{
+		{"below min", "timeouts.zero", minTimeout, "duration below minimum, clamping"},
+		{"above max", "timeouts.huge", maxTimeout, "duration above maximum, clamping"},
+		{"in range", "timeouts.ok", 30 * time.Second, ""},
+		{"unparseable", "timeouts.bogus", def, "invalid duration, using default"},
+		{"missing", "timeouts.missing", def, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler.records = nil
+			assert.Equal(t, tt.want, cfg.GetDurationClamped(tt.key, minTimeout, maxTimeout, def))
+			if tt.wantWarn == "" {
+				assert.Empty(t, handler.records)
+				return
+			}
+			require.Len(t, handler.records, 1)
+			assert.Equal(t, slog.LevelWarn, handler.records[0].Level)
+			assert.Equal(t, tt.wantWarn, handler.records[0].Message)
+		})
+	}
+}
+
+func TestNewAPI_MixedTypeSliceAfterProfileMerge(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("ports: [80]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-prod.yaml"), []byte("ports:\n  - 80\n  - ${PORT}\n  - 8443.0\n"), 0644))
+
+	cfg, err := LoadWithProfile(filepath.Join(dir, "app.yaml"), "prod", WithEnvLookup(func(name string) (string, bool) {
+		return map[string]string{"PORT": "443"}[name], name == "PORT"
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{80, 443, 8443}, cfg.GetIntSlice("ports"))
+	assert.Equal(t, []string{"80", "443", "8443"}, cfg.GetStringSlice("ports"))
+
+	// Mappings inside lists are substituted entry by entry as well
+	lookup := WithEnvLookup(func(name string) (string, bool) { return "", false })
+	cfg = FromMap(map[string]interface{}{
+		"replicas": []interface{}{
+			map[string]interface{}{"host": "${HOST:x}", "port": 5432},
+			map[interface{}]interface{}{"host": "${HOST:y}", "tls": true},
+		},
+	}, WithEnvSubstitution(true), lookup)
+	replicas, ok := cfg.Get("replicas")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"host": "x", "port": 5432},
+		map[interface{}]interface{}{"host": "y", "tls": true},
+	}, replicas)
+}
+
+func TestNewAPI_SelfReferentialStruct(t *testing.T) {
+	type Node struct {
+		Name     string `konfig:"name"`
+		Next     *Node  `konfig:"next"`
+		Children []Node `konfig:"children"`
+	}
+	type Config struct {
+		Primary Node `konfig:"primary"`
+		Replica Node `konfig:"replica"` // same type twice is not a cycle
+	}
+
+	cfg := FromMap(map[string]interface{}{
+		"primary": map[string]interface{}{"name": "a", "next": map[string]interface{}{"name": "b"}},
+		"replica": map[string]interface{}{"name": "c"},
+	})
+
+	var target Config
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, "a", target.Primary.Name)
+	assert.Nil(t, target.Primary.Next, "pointer fields are not followed")
+	assert.Equal(t, "c", target.Replica.Name)
+
+	// Nesting is bounded by the YAML depth limit
+	nested := func(levels int) reflect.Type {
+		typ := reflect.TypeOf(struct {
+			Leaf string `konfig:"leaf"`
+		}{})
+		for i := 0; i < levels; i++ {
+			typ = reflect.StructOf([]reflect.StructField{{Name: "Next", Type: typ, Tag: `konfig:"n"`}})
+		}
+		return typ
+	}
+	require.NoError(t, cfg.Unmarshal(reflect.New(nested(maxNestingDepth-1)).Interface()))
+
+	err := cfg.Unmarshal(reflect.New(nested(maxNestingDepth)).Interface())
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "validation_error", configErr.Type)
+	assert.Equal(t, strings.TrimSuffix(strings.Repeat("n.", maxNestingDepth), "."), configErr.Path)
+	assert.Contains(t, configErr.Message, "struct nesting exceeds maximum depth of 32")
+}
+
+func TestNewAPI_GetStringWholeFloats(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	content := "big: 10000000000\nbig_float: 1.0e10\naccount_id: 12345678901.0\nratio: 0.5\ntiny: 1.5e-7\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "10000000000", cfg.GetString("big"))
+	assert.Equal(t, "10000000000", cfg.GetString("big_float"))
+	assert.Equal(t, "12345678901", cfg.GetString("account_id"))
+	assert.NotContains(t, cfg.GetString("big_float"), "e+")
+	assert.Equal(t, "0.5", cfg.GetString("ratio"))
+	assert.Equal(t, "1.5e-07", cfg.GetString("tiny"))
+}
+
+func TestNewAPI_WithOverride(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte("server:\n  port: 8080\n  host: base\nlog:\n  level: info\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-prod.yaml"), []byte("server:\n  port: 443\n  host: prod\n"), 0644))
+
+	opts := []Option{
+		WithOverride("server.port", 9000),
+		WithOverride("log", map[string]interface{}{"level": "debug"}),
+		WithOverride("server.host", "first"),
+		WithOverride("server.host", "override"),
+		WithProvenance(),
+	}
+	cfg, err := LoadWithProfile(basePath, "prod", opts...)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9000, cfg.GetInt("server.port"), "override beats base and profile")
+	assert.Equal(t, "override", cfg.GetString("server.host"), "last override wins")
+	assert.Equal(t, "debug", cfg.GetString("log.level"))
+	assert.Equal(t, "WithOverride", cfg.Source("server.port"))
+
+	// Overrides survive a reload of the files
+	require.NoError(t, os.WriteFile(basePath, []byte("server:\n  port: 1\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 9000, cfg.GetInt("server.port"))
+
+	var target struct {
+		Port int `konfig:"server.port"`
+	}
+	require.NoError(t, LoadInto(basePath, &target, WithOverride("server.port", "7000")))
+	assert.Equal(t, 7000, target.Port)
+}
+
+func TestNewAPI_LoadIntoReport(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 9090\n"), 0644))
+	t.Setenv("KONFIG_TEST_REPORT_TOKEN", "secret")
+
+	type Server struct {
+		Port int    `konfig:"port" default:"8080"`
+		Host string `konfig:"host" default:"localhost"`
+	}
+	type Config struct {
+		Server Server `konfig:"server"`
+		Token  string `konfig:"auth.token" env:"KONFIG_TEST_REPORT_TOKEN"`
+		Debug  bool   `konfig:"debug"`
+	}
+
+	var target Config
+	report, err := LoadIntoReport(configPath, &target)
+	require.NoError(t, err)
+
+	assert.Equal(t, []FieldReport{
+		{Field: "Config.Server.Port", Key: "server.port", Source: FromConfig},
+		{Field: "Config.Server.Host", Key: "server.host", Source: FromDefault},
+		{Field: "Config.Token", Key: "auth.token", Source: FromEnv},
+		{Field: "Config.Debug", Key: "debug", Source: FromNone},
+	}, report.Fields)
+	assert.Equal(t, 1, report.Count(FromDefault))
+
+	// A struct type used twice reports each use under its own field
+	type Cluster struct {
+		Primary Server `konfig:"primary"`
+		Replica Server
+	}
+	var cluster Cluster
+	report, err = LoadIntoReport(configPath, &cluster)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Cluster.Primary.Port", "Cluster.Primary.Host",
+		"Cluster.Replica.Port", "Cluster.Replica.Host",
+	}, []string{
+		report.Fields[0].Field, report.Fields[1].Field,
+		report.Fields[2].Field, report.Fields[3].Field,
+	})
+	assert.Equal(t, "replica.port", report.Fields[2].Key)
+
+	// Plain LoadInto fills the struct the same way
+	var plain Config
+	require.NoError(t, LoadInto(configPath, &plain))
+	assert.Equal(t, target, plain)
+}
+
+func TestNewAPI_GetAs(t *testing.T) {
+	type endpoint struct{ Host string }
+	cfg := FromMap(map[string]interface{}{
+		"port":     8080,
+		"name":     "api",
+		"tags":     []interface{}{"a", "b"},
+		"endpoint": endpoint{Host: "db.local"},
+	})
+
+	port, ok := GetAs[int](cfg, "port")
+	assert.True(t, ok)
+	assert.Equal(t, 8080, port)
+
+	ep, ok := GetAs[endpoint](cfg, "endpoint")
+	assert.True(t, ok)
+	assert.Equal(t, "db.local", ep.Host)
+
+	tags, ok := GetAs[[]interface{}](cfg, "tags")
+	assert.True(t, ok)
+	assert.Len(t, tags, 2)
+
+	name, ok := GetAs[int](cfg, "name")
+	assert.False(t, ok, "type mismatch")
+	assert.Zero(t, name)
+
+	_, ok = GetAs[string](cfg, "missing")
+	assert.False(t, ok)
+}
+
+func TestNewAPI_Int(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"port":     8080,
+		"timeout":  30.9,
+		"workers":  " 4 ",
+		"name":     "api",
+		"empty":    "",
+		"null":     nil,
+		"huge":     "1e300",
+		"overflow": "99999999999999999999",
+		"inf":      "1e400",
+	})
+
+	tests := []struct {
+		key     string
+		value   int
+		present bool
+		wantErr string
+	}{
+		{"port", 8080, true, ""},
+		{"timeout", 30, true, ""},
+		{"workers", 4, true, ""},
+		{"name", 0, true, "value 'api' is not an integer"},
+		{"huge", 0, true, "value '1e300' is out of range for int"},
+		{"overflow", 0, true, "value '99999999999999999999' is out of range for int"},
+		{"inf", 0, true, "value '1e400' is out of range for int"},
+		{"empty", 0, false, ""},
+		{"null", 0, false, ""},
+		{"missing", 0, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, present, err := cfg.Int(tt.key)
+			assert.Equal(t, tt.value, value)
+			assert.Equal(t, tt.present, present)
+			if tt.wantErr != "" {
+				var configErr *ConfigError
+				require.ErrorAs(t, err, &configErr)
+				assert.Equal(t, "type_error", configErr.Type)
+				assert.Equal(t, tt.key, configErr.Path)
+				assert.Equal(t, tt.wantErr, configErr.Message)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, value, cfg.GetInt(tt.key))
+		})
+	}
+}
+
+func TestNewAPI_DurationUnitTag(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"cache": map[string]interface{}{
+			"ttl":      300,
+			"poll":     250,
+			"stale":    "5m",
+			"untagged": 300,
+		},
+	})
+
+	var tagged struct {
+		TTL    time.Duration `konfig:"cache.ttl" unit:"s"`
+		Poll   time.Duration `konfig:"cache.poll" unit:"ms"`
+		Stale  time.Duration `konfig:"cache.stale" unit:"ms"` // strings with units are parsed as usual
+		Retain time.Duration `konfig:"cache.retain" unit:"h" default:"2"`
+	}
+	require.NoError(t, cfg.Unmarshal(&tagged))
+	assert.Equal(t, 300*time.Second, tagged.TTL)
+	assert.Equal(t, 250*time.Millisecond, tagged.Poll)
+	assert.Equal(t, 5*time.Minute, tagged.Stale)
+	assert.Equal(t, 2*time.Hour, tagged.Retain)
+
+	t.Run("untagged bare numbers", func(t *testing.T) {
+		for name, target := range map[string]interface{}{
+			"native": &struct {
+				Untagged time.Duration `konfig:"cache.untagged"`
+			}{},
+			"default": &struct {
+				Retain time.Duration `konfig:"cache.retain" default:"2"`
+			}{},
+		} {
+			var configErr *ConfigError
+			require.ErrorAs(t, cfg.Unmarshal(target), &configErr, name)
+			assert.Equal(t, "type_error", configErr.Type, name)
+			assert.Contains(t, configErr.Message, "expected time.Duration", name)
+		}
+	})
+
+	t.Run("unknown unit", func(t *testing.T) {
+		var target struct {
+			TTL time.Duration `konfig:"cache.ttl" unit:"d"`
+		}
+		var configErr *ConfigError
+		require.ErrorAs(t, cfg.Unmarshal(&target), &configErr)
+		assert.Equal(t, "validation_error", configErr.Type)
+	})
+}
+
+func TestNewAPI_RequireProfileFile(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte("server:\n  port: 8080\n"), 0644))
+
+	t.Run("dev skips missing file", func(t *testing.T) {
+		cfg, err := LoadWithProfile(basePath, "dev", WithRequireProfileFile("prod"))
+		require.NoError(t, err)
+		assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	})
+
+	t.Run("prod requires file", func(t *testing.T) {
+		_, err := LoadWithProfile(basePath, "prod", WithRequireProfileFile("prod"))
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, "file_not_found", configErr.Type)
+		assert.Equal(t, filepath.Join(filepath.Dir(basePath), "app-prod.yaml"), configErr.Path)
+	})
+
+	t.Run("no arguments requires every profile", func(t *testing.T) {
+		_, err := LoadWithProfile(basePath, "dev", WithRequireProfileFile())
+		require.Error(t, err)
+	})
+
+	t.Run("default skips missing file", func(t *testing.T) {
+		_, err := LoadWithProfile(basePath, "prod")
+		require.NoError(t, err)
+	})
+}
+
+func TestNewAPI_ConversionErrorNamesKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: localhost\n"), 0644))
+
+	type Server struct {
+		Port int `konfig:"port"`
+	}
+	type Config struct {
+		Server Server `konfig:"server"`
+	}
+
+	var target Config
+	err := LoadInto(configPath, &target)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "type_error", configErr.Type)
+	assert.Equal(t, "Server.Port", configErr.Path)
+	assert.Equal(t, "failed to set field from config key 'server.port': expected int", configErr.Message)
+	assert.Contains(t, err.Error(), "cannot convert 'localhost' to int")
+
+	t.Setenv("KONFIG_TEST_BAD_TIMEOUT", "soon")
+	var fromEnv struct {
+		Timeout time.Duration `konfig:"server.timeout" env:"KONFIG_TEST_BAD_TIMEOUT"`
+		Size    int64         `konfig:"server.size" default:"huge" format:"bytes"`
+	}
+	err = LoadInto(configPath, &fromEnv)
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "failed to set field from environment variable KONFIG_TEST_BAD_TIMEOUT (config key 'server.timeout'): expected time.Duration", configErr.Message)
+
+	t.Setenv("KONFIG_TEST_BAD_TIMEOUT", "")
+	err = LoadInto(configPath, &fromEnv)
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "failed to set field from default tag of config key 'server.size': expected int64 in format bytes", configErr.Message)
+}
+
+func TestNewAPI_StringMapFieldWithSubstitution(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	configContent := `
+labels:
+  region: ${REGION:us}
+  team: core
+  tier:
+    name: gold
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type Config struct {
+		Labels map[string]string `konfig:"labels"`
+		Extra  map[string]string `konfig:"extra"`
+	}
+
+	var target Config
+	require.NoError(t, LoadInto(configPath, &target))
+	assert.Equal(t, map[string]string{"region": "us", "team": "core", "tier.name": "gold"}, target.Labels)
+	assert.Nil(t, target.Extra, "absent maps stay nil")
+
+	var withEnv Config
+	require.NoError(t, LoadInto(configPath, &withEnv, WithEnvLookup(func(name string) (string, bool) {
+		return "eu-west-1", name == "REGION"
+	})))
+	assert.Equal(t, "eu-west-1", withEnv.Labels["region"])
+
+	var typed Config
+	require.NoError(t, LoadIntoTyped(configPath, &typed))
+	assert.Equal(t, target.Labels, typed.Labels)
+
+	// Map values are formatted like string fields under the same keys
+	formatted := FromMap(map[string]interface{}{
+		"meta": map[string]interface{}{
+			"built": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			"id":    1e10,
+		},
+	})
+	var meta struct {
+		Meta  map[string]string `konfig:"meta"`
+		Built string            `konfig:"meta.built"`
+		ID    string            `konfig:"meta.id"`
+	}
+	require.NoError(t, formatted.Unmarshal(&meta))
+	assert.Equal(t, map[string]string{"built": "2024-01-02T03:04:05Z", "id": "10000000000"}, meta.Meta)
+	assert.Equal(t, meta.Built, meta.Meta["built"])
+	assert.Equal(t, meta.ID, meta.Meta["id"])
+
+	values, err := formatted.GetStringMapE("meta")
+	require.NoError(t, err)
+	assert.Equal(t, meta.Meta, values)
+}
+
+func TestNewAPI_Equal(t *testing.T) {
+	base := map[string]interface{}{
+		"server": map[string]interface{}{"port": 8080, "host": "localhost"},
+		"ports":  []interface{}{80, 443},
+		"limits": []interface{}{map[string]interface{}{"rps": 10}},
+	}
+	a := FromMap(base)
+	b := FromMap(map[string]interface{}{
+		"limits": []interface{}{map[string]interface{}{"rps": 10}},
+		"ports":  []interface{}{80, 443},
+		"server": map[string]interface{}{"host": "localhost", "port": 8080},
+	})
+	assert.True(t, Equal(a, b), "order does not matter")
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 8080\n  host: localhost\nports: [80, 443]\nlimits:\n  - rps: 10\n"), 0644))
+	loaded, err := Load(configPath)
+	require.NoError(t, err)
+	assert.True(t, Equal(a, loaded))
+
+	tests := []struct {
+		name  string
+		other Config
+	}{
+		{"different value", FromMap(map[string]interface{}{"server.port": 9090, "server.host": "localhost", "ports": []interface{}{80, 443}, "limits": []interface{}{map[string]interface{}{"rps": 10}}})},
+		{"different slice", FromMap(map[string]interface{}{"server.port": 8080, "server.host": "localhost", "ports": []interface{}{80}, "limits": []interface{}{map[string]interface{}{"rps": 10}}})},
+		{"different nested map", FromMap(map[string]interface{}{"server.port": 8080, "server.host": "localhost", "ports": []interface{}{80, 443}, "limits": []interface{}{map[string]interface{}{"rps": 20}}})},
+		{"missing key", FromMap(map[string]interface{}{"server.port": 8080, "ports": []interface{}{80, 443}, "limits": []interface{}{map[string]interface{}{"rps": 10}}})},
+		{"int versus float", FromMap(map[string]interface{}{"server.port": 8080.0, "server.host": "localhost", "ports": []interface{}{80, 443}, "limits": []interface{}{map[string]interface{}{"rps": 10}}})},
+		{"nil", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.False(t, Equal(a, tt.other))
+			assert.False(t, Equal(tt.other, a))
+		})
+	}
+
+	nan := FromMap(map[string]interface{}{"ratio": math.NaN()})
+	assert.False(t, Equal(nan, nan), "NaN never equals itself")
+	assert.True(t, Equal(nil, nil))
+}
+
+func TestNewAPI_WithDefaults(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+db:
+  host: ${DB_HOST}
+  port: ${DB_PORT:5432}
+  user: ${DB_USER}
+  name: ${DB_NAME:}
+  region: ${REGION}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	env := map[string]string{"DB_HOST": "db.prod"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	defaults := map[string]string{
+		"DB_HOST": "db.default",
+		"DB_PORT": "6543",
+		"DB_USER": "app",
+		"DB_NAME": "fallback",
+	}
+
+	cfg, err := Load(configPath, WithEnvLookup(lookup), WithDefaults(defaults))
+	require.NoError(t, err)
+	assert.Equal(t, "db.prod", cfg.GetString("db.host"), "environment wins")
+	assert.Equal(t, "5432", cfg.GetString("db.port"), "inline default beats defaults map")
+	assert.Equal(t, "app", cfg.GetString("db.user"), "defaults map used without inline default")
+	assert.Equal(t, "", cfg.GetString("db.name"), "an explicit empty inline default is kept")
+	assert.Equal(t, "", cfg.GetString("db.region"), "missing everywhere")
+
+	// The map is copied, so later changes do not leak into Reload
+	defaults["DB_USER"] = "changed"
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, "app", cfg.GetString("db.user"))
+}
+
+func TestNewAPI_LoadWithDefaults(t *testing.T) {
+	defaults := []byte(`
+server:
+  port: 8080
+  host: ${HOST:localhost}
+log:
+  level: info
+`)
+	tempDir := t.TempDir()
+	userPath := filepath.Join(tempDir, "config.yaml")
+
+	// No user file: defaults only
+	cfg, err := LoadWithDefaults(defaults, userPath)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	assert.Equal(t, "localhost", cfg.GetString("server.host"))
+
+	cfg, err = LoadWithDefaults(defaults, "")
+	require.NoError(t, err)
+	assert.Equal(t, "info", cfg.GetString("log.level"))
+
+	// The user file overrides individual keys
+	require.NoError(t, os.WriteFile(userPath, []byte("log:\n  level: debug\nextra: true\n"), 0644))
+	cfg, err = LoadWithDefaults(defaults, userPath)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.GetString("log.level"))
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+	assert.True(t, cfg.GetBool("extra"))
+
+	// Reload picks up user file changes
+	require.NoError(t, os.WriteFile(userPath, []byte("server:\n  port: 9090\n"), 0644))
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 9090, cfg.GetInt("server.port"))
+	assert.Equal(t, "info", cfg.GetString("log.level"))
+
+	// Broken input is reported
+	_, err = LoadWithDefaults([]byte("server: [unclosed"), userPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse default configuration")
+
+	require.NoError(t, os.WriteFile(userPath, []byte("server: [unclosed"), 0644))
+	_, err = LoadWithDefaults(defaults, userPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), userPath)
+}
+
+func TestNewAPI_SliceProfileIndexOverride(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte("allowed: [alpha, beta, gamma]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("allowed:\n  1: BETA\n"), 0644))
+
+	cfg, err := LoadWithProfile(basePath, "prod")
+	require.NoError(t, err)
+
+	// Both the whole list and the indexed key are present after the merge
+	_, hasList := cfg.Get("allowed")
+	_, hasIndex := cfg.Get("allowed.1")
+	require.True(t, hasList)
+	require.True(t, hasIndex)
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, []string{"alpha", "BETA", "gamma"}, cfg.GetStringSlice("allowed"))
+	}
+}
+
+func TestNewAPI_EmptySubstitutionWarning(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+db:
+  host: ${KONFIG_TEST_MISSING_HOST}
+  port: ${KONFIG_TEST_MISSING_PORT:5432}
+  name: ${KONFIG_TEST_MISSING_NAME:}
+  user: ${KONFIG_TEST_DEFAULTED_USER}
+  password: ${KONFIG_TEST_EMPTY_PASSWORD}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+	t.Setenv("KONFIG_TEST_EMPTY_PASSWORD", "")
+
+	handler := &recordingHandler{}
+	cfg, err := Load(configPath,
+		WithLogger(slog.New(handler)),
+		WithDefaults(map[string]string{"KONFIG_TEST_DEFAULTED_USER": "app"}))
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.GetString("db.host"))
+
+	require.Len(t, handler.records, 2, "only placeholders without any default warn")
+	warnings := map[string]string{}
+	for _, record := range handler.records {
+		assert.Equal(t, slog.LevelWarn, record.Level)
+		attrs := map[string]string{}
+		record.Attrs(func(attr slog.Attr) bool {
+			attrs[attr.Key] = attr.Value.String()
+			return true
+		})
+		warnings[attrs["key"]] = attrs["variable"] + ": " + record.Message
+	}
+	assert.Equal(t, map[string]string{
+		"db.host":     "KONFIG_TEST_MISSING_HOST: environment variable not set and no default given",
+		"db.password": "KONFIG_TEST_EMPTY_PASSWORD: environment variable empty and no default given",
+	}, warnings)
+}
+
+// recordingHandler is a slog.Handler that keeps every record it receives
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+func TestNewAPI_KeyGroups(t *testing.T) {
+	type AppConfig struct {
+		CertFile string `konfig:"tls.cert_file"`
+		KeyFile  string `konfig:"tls.key_file"`
+		Token    string `konfig:"auth.token"`
+		Key      string `konfig:"auth.key"`
+	}
+	opts := []Option{
+		WithRequiredTogether("tls.cert_file", "tls.key_file"),
+		WithMutuallyExclusive("auth.token", "auth.key"),
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"both tls files", "tls:\n  cert_file: c.pem\n  key_file: k.pem\nauth:\n  token: t\n", ""},
+		{"neither tls file", "auth:\n  key: k\n", ""},
+		{"nothing set", "other: 1\n", ""},
+		{"empty value counts as unset", "tls:\n  cert_file: c.pem\n  key_file: \"\"\n", "keys must be set together: tls.cert_file set but tls.key_file missing"},
+		{"only cert file", "tls:\n  cert_file: c.pem\n", "keys must be set together: tls.cert_file set but tls.key_file missing"},
+		{"both credentials", "auth:\n  token: t\n  key: k\n", "keys are mutually exclusive but auth.token, auth.key are all set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "app.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tt.content), 0644))
+
+			var target AppConfig
+			err := LoadInto(configPath, &target, opts...)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			var configErr *ConfigError
+			require.ErrorAs(t, err, &configErr)
+			assert.Equal(t, "validation_error", configErr.Type)
+			assert.Equal(t, tt.wantErr, configErr.Message)
+		})
+	}
+}
+
+func TestNewAPI_DurationStoredRepresentations(t *testing.T) {
+	cfg := FromMap(map[string]interface{}{
+		"native":  90 * time.Second,
+		"seconds": 30,
+		"float":   1.5,
+		"string":  "2m",
+		"days":    "1d",
+		"invalid": "soon",
+		"huge":    10000000000,
+		"list":    []interface{}{5 * time.Second, 10, "1m"},
+	})
+
+	assert.Equal(t, 90*time.Second, cfg.GetDuration("native"))
+	assert.Equal(t, 30*time.Second, cfg.GetDuration("seconds"))
+	assert.Equal(t, 1500*time.Millisecond, cfg.GetDuration("float"))
+	assert.Equal(t, 2*time.Minute, cfg.GetDuration("string"))
+	assert.Equal(t, 24*time.Hour, cfg.GetDuration("days"))
+	assert.Equal(t, time.Duration(0), cfg.GetDuration("invalid"))
+	assert.Equal(t, time.Duration(0), cfg.GetDuration("huge"), "seconds beyond time.Duration do not wrap")
+	assert.Equal(t, time.Minute, cfg.GetDurationClamped("huge", time.Second, time.Hour, time.Minute))
+	assert.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, time.Minute}, cfg.GetDurationSlice("list"))
+
+	// YAML integers are read as seconds as well
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("timeout: 45\nquoted: \"30\"\nfrom_env: ${KONFIG_TEST_TIMEOUT}\n"), 0644))
+	t.Setenv("KONFIG_TEST_TIMEOUT", "20")
+	loaded, err := Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, loaded.GetDuration("timeout"))
+
+	// ...however the number was written
+	assert.Equal(t, 30*time.Second, loaded.GetDuration("quoted"))
+	assert.Equal(t, 20*time.Second, loaded.GetDuration("from_env"))
+
+	// Struct fields take native durations and duration strings; bare
+	// numbers need a unit tag
+	type Timeouts struct {
+		Native  time.Duration `konfig:"native"`
+		Seconds time.Duration `konfig:"seconds" unit:"s"`
+		String  time.Duration `konfig:"string"`
+	}
+	var target Timeouts
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, Timeouts{Native: 90 * time.Second, Seconds: 30 * time.Second, String: 2 * time.Minute}, target)
+
+	for name, field := range map[string]interface{}{
+		"native number": &struct {
+			Timeout time.Duration `konfig:"timeout"`
+		}{},
+		"env tag": &struct {
+			Timeout time.Duration `konfig:"missing.env" env:"KONFIG_TEST_TIMEOUT"`
+		}{},
+		"default tag": &struct {
+			Timeout time.Duration `konfig:"missing.default" default:"30"`
+		}{},
+	} {
+		var configErr *ConfigError
+		require.ErrorAs(t, loaded.Unmarshal(field), &configErr, name)
+		assert.Equal(t, "type_error", configErr.Type, name)
+	}
+	var list struct {
+		Timeouts []time.Duration `konfig:"list"`
+	}
+	err = cfg.Unmarshal(&list)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "element 1 '10'")
+
+	var huge struct {
+		Timeout time.Duration `konfig:"huge" unit:"s"`
+	}
+	err = cfg.Unmarshal(&huge)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of range")
+}
+
+func TestNewAPI_MergeInto(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	configContent := `
+server:
+  port: 9090
+  timeout: 5s
+database:
+  host: ""
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	type Config struct {
+		Port    int           `konfig:"server.port" default:"8080"`
+		Host    string        `konfig:"server.host" default:"0.0.0.0"`
+		Timeout time.Duration `konfig:"server.timeout"`
+		Debug   bool          `konfig:"debug" default:"true"`
+		DBHost  string        `konfig:"database.host"`
+		ID      string        `konfig:"instance.id" defaultFunc:"uuid"`
+	}
+
+	target := Config{Port: 1, Host: "localhost", Timeout: time.Second, DBHost: "db.local", ID: "fixed"}
+	require.NoError(t, MergeInto(configPath, &target))
+
+	assert.Equal(t, Config{
+		Port:    9090,            // present in config
+		Host:    "localhost",     // pre-set value kept over default tag
+		Timeout: 5 * time.Second, // present in config
+		Debug:   false,           // zero value kept, default tag ignored
+		DBHost:  "db.local",      // empty value counts as absent
+		ID:      "fixed",         // defaultFunc ignored
+	}, target)
+
+	// LoadInto applies defaults for comparison
+	var loaded Config
+	require.NoError(t, LoadInto(configPath, &loaded))
+	assert.Equal(t, "0.0.0.0", loaded.Host)
+	assert.True(t, loaded.Debug)
+}
+
+func TestNewAPI_LoadIntoWithDefaults(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 9090\n"), 0644))
+
+	type Config struct {
+		Port   int               `konfig:"server.port"`
+		Hosts  []string          `konfig:"server.hosts"`
+		Labels map[string]string `konfig:"labels"`
+	}
+	defaults := Config{
+		Port:   8080,
+		Hosts:  []string{"a.local", "b.local"},
+		Labels: map[string]string{"team": "core"},
+	}
+
+	var target Config
+	require.NoError(t, LoadIntoWithDefaults(configPath, &defaults, &target))
+	assert.Equal(t, Config{
+		Port:   9090, // present in config
+		Hosts:  []string{"a.local", "b.local"},
+		Labels: map[string]string{"team": "core"},
+	}, target)
+	assert.Equal(t, 8080, defaults.Port, "defaults must not be modified")
+
+	t.Run("mismatched defaults type", func(t *testing.T) {
+		err := LoadIntoWithDefaults(configPath, struct{ Port int }{}, &target)
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, "validation_error", configErr.Type)
+	})
+
+	t.Run("load error leaves target untouched", func(t *testing.T) {
+		var untouched Config
+		err := LoadIntoWithDefaults(filepath.Join(t.TempDir(), "missing.yaml"), defaults, &untouched)
+		require.Error(t, err)
+		assert.Equal(t, Config{}, untouched)
+	})
+
+	t.Run("population error leaves target untouched", func(t *testing.T) {
+		badPath := filepath.Join(t.TempDir(), "bad.yaml")
+		require.NoError(t, os.WriteFile(badPath, []byte("server:\n  port: localhost\n  hosts: [x]\n"), 0644))
+
+		untouched := Config{Port: 1}
+		err := LoadIntoWithDefaults(badPath, defaults, &untouched)
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, "type_error", configErr.Type)
+		assert.Equal(t, Config{Port: 1}, untouched)
+
+		err = LoadIntoWithDefaults(configPath, defaults, &untouched, WithRequiredTogether("server.port", "server.tls"))
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, "validation_error", configErr.Type)
+		assert.Equal(t, Config{Port: 1}, untouched)
+	})
+}
+
+func TestNewAPI_KeyDelimiter(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "app.yaml")
+	configContent := `
+hosts:
+  app.example.com:
+    port: 443
+    tags: [edge, public]
+  api.example.com:
+    port: 8443
+server.name: legacy
+`
+	require.NoError(t, os.WriteFile(basePath, []byte(configContent), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte("hosts:\n  api.example.com:\n    port: 9443\n"), 0644))
+
+	cfg, err := LoadWithProfile(basePath, "prod", WithKeyDelimiter("/"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 443, cfg.GetInt("hosts/app.example.com/port"))
+	assert.Equal(t, 9443, cfg.GetInt("hosts/api.example.com/port"))
+	assert.Equal(t, "legacy", cfg.GetString("server.name"), "dots are ordinary key characters")
+	assert.Equal(t, []string{"edge", "public"}, cfg.GetStringSlice("hosts/app.example.com/tags"))
+	assert.Equal(t, map[string]string{"port": "443", "tags": "[edge public]"}, cfg.GetStringMap("hosts/app.example.com"))
+
+	subs := cfg.GetSubConfigs("hosts")
+	require.Len(t, subs, 2)
+	assert.Equal(t, 443, subs["app.example.com"].GetInt("port"))
+
+	cfg.Set("hosts/new.example.com", map[string]interface{}{"port": 80})
+	assert.Equal(t, 80, cfg.GetInt("hosts/new.example.com/port"))
+
+	type Host struct {
+		Port int `konfig:"port"`
+	}
+	type Hosts struct {
+		App  Host   `konfig:"hosts/app.example.com"`
+		API  Host   `konfig:"hosts/api.example.com"`
+		Name string `konfig:"server.name"`
+	}
+	var target Hosts
+	require.NoError(t, cfg.Unmarshal(&target))
+	assert.Equal(t, Hosts{App: Host{Port: 443}, API: Host{Port: 9443}, Name: "legacy"}, target)
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"app.example.com":{"port":443`)
+
+	// With the default delimiter the dotted host names are ambiguous
+	dotted, err := Load(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, 443, dotted.GetInt("hosts.app.example.com.port"))
+	assert.Len(t, dotted.GetSubConfigs("hosts"), 2, "app and api, not the full host names")
+}
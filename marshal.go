@@ -0,0 +1,57 @@
+package konfig
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// redactedValue replaces secret values in marshaled output
+const redactedValue = "[REDACTED]"
+
+// MarshalJSON un-flattens the dotted keys into nested JSON objects
+func (c *config) MarshalJSON() ([]byte, error) {
+	data := c.snapshot()
+
+	entries := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if c.isSecretKey(key) {
+			entries[key] = redactedValue
+		} else {
+			entries[key] = jsonValue(value)
+		}
+	}
+
+	return json.Marshal(unflattenMap(entries, c.sep()))
+}
+
+// isSecretKey reports whether key is, or lies below, a registered secret key
+func (c *config) isSecretKey(key string) bool {
+	for _, secret := range c.secretKeys {
+		if secret == "" || key == secret || strings.HasPrefix(key, secret+c.sep()) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonValue converts YAML values that encoding/json cannot handle, such as
+// mappings with non-string keys
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		return jsonValue(stringifyMapKeys(v))
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = jsonValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = jsonValue(item)
+		}
+		return result
+	}
+	return value
+}
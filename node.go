@@ -0,0 +1,307 @@
+package konfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a YAML configuration loaded with its syntax tree intact
+//
+// Unlike Config, which flattens values into a map, a Document keeps the
+// original node structure, so comments, key order and formatting survive a
+// Set followed by WriteTo. It is intended for config-editing tools; readers
+// should prefer Load.
+type Document struct {
+	path string
+	root *yaml.Node
+	mu   sync.RWMutex
+}
+
+// LoadNode loads a YAML file as an editable Document
+//
+// The same path, size and complexity limits as Load apply. Environment
+// variable placeholders are kept verbatim so they are written back unchanged.
+//
+// Example:
+//
+//	doc, err := konfig.LoadNode("./config/app.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	_ = doc.Set("server.port", 9090)
+//	_, err = doc.WriteTo(os.Stdout)
+func LoadNode(filePath string) (*Document, error) {
+	if filePath == "" {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    filePath,
+			Message: "file path cannot be empty",
+		}
+	}
+
+	if !fileExists(filePath) {
+		return nil, &ConfigError{
+			Type:    "file_not_found",
+			Path:    filePath,
+			Message: "configuration file not found",
+		}
+	}
+
+	data, err := readConfigFile(filePath, applyOptions(nil))
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    filePath,
+			Message: "failed to read YAML file",
+			Cause:   err,
+		}
+	}
+
+	root, err := parseYAMLNode(data)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    filePath,
+			Message: "failed to parse YAML file",
+			Cause:   err,
+		}
+	}
+
+	return &Document{path: filePath, root: root}, nil
+}
+
+// parseYAMLNode parses data into a document node with complexity validation
+func parseYAMLNode(data []byte) (*yaml.Node, error) {
+	var root yaml.Node
+	if !isBlankDocument(data) {
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	}
+
+	// Empty, comment-only and null documents start from an empty mapping
+	if root.Kind == 0 {
+		root = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	} else if len(root.Content) == 1 && root.Content[0].Tag == "!!null" {
+		null := root.Content[0]
+		root.Content[0] = &yaml.Node{
+			Kind:        yaml.MappingNode,
+			Tag:         "!!map",
+			HeadComment: null.HeadComment,
+			FootComment: null.FootComment,
+		}
+	}
+
+	// Security: Validate YAML complexity
+	var decoded interface{}
+	if err := root.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if err := validateYAMLComplexity(decoded, 0); err != nil {
+		return nil, fmt.Errorf("YAML too complex: %w", err)
+	}
+
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("document root must be a mapping")
+	}
+
+	return &root, nil
+}
+
+// Get returns the decoded value at a dot-separated key and whether it exists
+//
+// Mappings and sequences are returned as map[string]interface{} and
+// []interface{} respectively.
+func (d *Document) Get(key string) (interface{}, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	node := d.lookup(key)
+	if node == nil {
+		return nil, false
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set replaces the value at a dot-separated key, creating intermediate
+// mappings as needed
+//
+// Comments attached to a replaced value are carried over to the new value.
+func (d *Document) Set(key string, value interface{}) error {
+	if key == "" {
+		return &ConfigError{
+			Type:    "validation_error",
+			Path:    d.path,
+			Message: "key cannot be empty",
+		}
+	}
+
+	var newNode yaml.Node
+	if err := newNode.Encode(value); err != nil {
+		return &ConfigError{
+			Type:    "type_error",
+			Path:    key,
+			Message: "value cannot be represented as YAML",
+			Cause:   err,
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := d.root.Content[0]
+	segments := strings.Split(key, ".")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		if current.Kind != yaml.MappingNode {
+			return &ConfigError{
+				Type:    "validation_error",
+				Path:    key,
+				Message: fmt.Sprintf("cannot set key below non-mapping value at '%s'", strings.Join(segments[:i], ".")),
+			}
+		}
+
+		valueIndex := mappingValueIndex(current, segment)
+		if valueIndex < 0 {
+			child := &newNode
+			if !last {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			current.Content = append(current.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment},
+				child,
+			)
+			current = child
+			continue
+		}
+
+		if last {
+			old := current.Content[valueIndex]
+			newNode.HeadComment = old.HeadComment
+			newNode.LineComment = old.LineComment
+			newNode.FootComment = old.FootComment
+			current.Content[valueIndex] = &newNode
+			break
+		}
+
+		current = resolveAlias(current.Content[valueIndex])
+	}
+
+	return nil
+}
+
+// Comment returns the comment documenting a dot-separated key: the comment
+// lines directly above it followed by the comment at the end of its line,
+// without the leading "#", joined by newlines
+//
+// Comments are only kept by the Document loaded with LoadNode; the flattened
+// Config returned by Load discards them. Missing keys and keys without a
+// comment return "".
+//
+//	# Port the HTTP server listens on
+//	port: 8080 # privileged ports need root
+//
+// gives "Port the HTTP server listens on\nprivileged ports need root".
+func (d *Document) Comment(key string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	keyNode, valueNode := d.lookupEntry(key)
+	if keyNode == nil {
+		return ""
+	}
+
+	var lines []string
+	for _, comment := range []string{keyNode.HeadComment, keyNode.LineComment, valueNode.LineComment} {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteTo writes the document as YAML, preserving the original comments
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	d.mu.RLock()
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(d.root)
+	if err == nil {
+		err = encoder.Close()
+	}
+	d.mu.RUnlock()
+
+	if err != nil {
+		return 0, &ConfigError{
+			Type:    "parse_error",
+			Path:    d.path,
+			Message: "failed to encode YAML document",
+			Cause:   err,
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// lookup walks the node tree along a dot-separated key
+func (d *Document) lookup(key string) *yaml.Node {
+	_, valueNode := d.lookupEntry(key)
+	if valueNode == nil {
+		return nil
+	}
+	return resolveAlias(valueNode)
+}
+
+// lookupEntry returns the key and value nodes of the mapping entry for a
+// dot-separated key; the value node is returned as written, aliases unresolved
+func (d *Document) lookupEntry(key string) (*yaml.Node, *yaml.Node) {
+	var keyNode, valueNode *yaml.Node
+	current := d.root.Content[0]
+	for _, segment := range strings.Split(key, ".") {
+		if current.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		valueIndex := mappingValueIndex(current, segment)
+		if valueIndex < 0 {
+			return nil, nil
+		}
+		keyNode, valueNode = current.Content[valueIndex-1], current.Content[valueIndex]
+		current = resolveAlias(valueNode)
+	}
+	return keyNode, valueNode
+}
+
+// mappingValueIndex returns the index of the value node for key, or -1
+func mappingValueIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return node.Alias
+	}
+	return node
+}
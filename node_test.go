@@ -0,0 +1,102 @@
+package konfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_SetPreservesComments(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "app.yaml")
+
+	configContent := `# Application settings
+server:
+  # Port the HTTP server listens on
+  port: 8080 # keep in sync with the load balancer
+  host: localhost
+database:
+  url: ${DATABASE_URL:postgres://localhost/app}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	doc, err := LoadNode(configPath)
+	require.NoError(t, err)
+
+	value, exists := doc.Get("server.port")
+	require.True(t, exists)
+	assert.Equal(t, 8080, value)
+
+	_, exists = doc.Get("server.missing")
+	assert.False(t, exists)
+
+	require.NoError(t, doc.Set("server.port", 9090))
+	require.NoError(t, doc.Set("server.tls.enabled", true))
+
+	value, exists = doc.Get("server.port")
+	require.True(t, exists)
+	assert.Equal(t, 9090, value)
+
+	var out bytes.Buffer
+	_, err = doc.WriteTo(&out)
+	require.NoError(t, err)
+
+	written := out.String()
+	assert.Contains(t, written, "# Application settings")
+	assert.Contains(t, written, "# Port the HTTP server listens on")
+	assert.Contains(t, written, "port: 9090 # keep in sync with the load balancer")
+	assert.Contains(t, written, "tls:\n    enabled: true")
+	assert.Contains(t, written, "${DATABASE_URL:postgres://localhost/app}")
+
+	// Setting below a scalar is rejected
+	err = doc.Set("server.host.name", "x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+}
+
+func TestDocument_Errors(t *testing.T) {
+	_, err := LoadNode("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation_error")
+
+	_, err = LoadNode("nonexistent.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file_not_found")
+
+	tempDir := t.TempDir()
+	listPath := filepath.Join(tempDir, "list.yaml")
+	require.NoError(t, os.WriteFile(listPath, []byte("- a\n- b\n"), 0644))
+
+	_, err = LoadNode(listPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse_error")
+}
+
+func TestDocument_Comment(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	configContent := `server:
+  # Port the HTTP server listens on
+  # (privileged ports need root)
+  port: 8080 # keep in sync with the load balancer
+  host: localhost
+  tls: # optional
+    enabled: false
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	doc, err := LoadNode(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Port the HTTP server listens on\n(privileged ports need root)\nkeep in sync with the load balancer", doc.Comment("server.port"))
+	assert.Equal(t, "optional", doc.Comment("server.tls"))
+	assert.Equal(t, "", doc.Comment("server.host"))
+	assert.Equal(t, "", doc.Comment("server.missing"))
+
+	// Set keeps the comments of a replaced value
+	require.NoError(t, doc.Set("server.port", 9090))
+	assert.Contains(t, doc.Comment("server.port"), "keep in sync with the load balancer")
+}
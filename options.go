@@ -0,0 +1,426 @@
+package konfig
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Option customizes how configuration is loaded
+type Option func(*options)
+
+// options holds the settings assembled from Option values
+type options struct {
+	// profileSeparators are tried in order between base name and profile
+	profileSeparators []string
+
+	// keyDelimiter joins the segments of flattened keys
+	keyDelimiter string
+
+	// maxFileSize bounds the bytes read per file or response; 0 disables the limit
+	maxFileSize int64
+
+	// maxKeys bounds the number of flattened keys per file; 0 disables the limit
+	maxKeys int
+
+	// profileSections merges profiles.{profile} subtrees over the top level
+	profileSections bool
+
+	// envSubstitution enables ${VAR} substitution
+	envSubstitution bool
+
+	// envLookup resolves variables for ${VAR} substitution
+	envLookup func(string) (string, bool)
+
+	// envDefaults resolves ${VAR} placeholders without an inline default
+	envDefaults map[string]string
+
+	// readAttempts and readBackoff configure retries of transient read errors
+	readAttempts int
+	readBackoff  time.Duration
+
+	// secretKeys are redacted when the configuration is marshaled
+	secretKeys []string
+
+	// warnUnusedKeys makes the LoadInto family log keys no field maps
+	warnUnusedKeys bool
+
+	// provenance records the origin of every key for Config.Source
+	provenance bool
+
+	// yaml11Bools reads unquoted yes/no/on/off values as booleans
+	yaml11Bools bool
+
+	// keepEmptySliceItems disables trimming and blank removal in GetStringSlice
+	keepEmptySliceItems bool
+
+	// conflictReport records keys set by more than one merged layer
+	conflictReport bool
+
+	// implicitFieldNames maps untagged scalar fields by their field name
+	implicitFieldNames bool
+
+	// keyGroups are cross-key rules checked by the LoadInto family
+	keyGroups []keyGroup
+
+	// logger receives warnings such as deprecated key usage
+	logger *slog.Logger
+
+	// arrayMerge controls how list values overlap during profile merges
+	arrayMerge arrayMergeMode
+
+	// requireProfileFile makes a missing profile file an error for the
+	// profiles in requiredProfiles, or for every profile when that is empty
+	requireProfileFile bool
+	requiredProfiles   []string
+
+	// strictSchema makes LoadWithSchema reject files without a version
+	strictSchema bool
+
+	// overrides are set after everything else has been loaded and merged
+	overrides []override
+}
+
+// override is a single WithOverride key and value
+type override struct {
+	key   string
+	value interface{}
+}
+
+// keyGroup is a rule over a set of keys checked after struct population
+type keyGroup struct {
+	keys      []string
+	exclusive bool // at most one key set; otherwise all or none
+}
+
+// arrayMergeMode selects how mergeConfigs combines list values
+type arrayMergeMode int
+
+const (
+	arrayMergeReplace      arrayMergeMode = iota // override list replaces base list
+	arrayMergeAppend                             // override items appended to base items
+	arrayMergeAppendUnique                       // appended, skipping items already present
+)
+
+// requiresProfileFile reports whether a missing file for profile is an error
+func (o options) requiresProfileFile(profile string) bool {
+	if !o.requireProfileFile {
+		return false
+	}
+	if len(o.requiredProfiles) == 0 {
+		return true
+	}
+	for _, required := range o.requiredProfiles {
+		if required == profile {
+			return true
+		}
+	}
+	return false
+}
+
+func applyOptions(opts []Option) options {
+	o := options{
+		profileSeparators: []string{"-"},
+		keyDelimiter:      ".",
+		maxFileSize:       maxFileSize,
+		maxKeys:           maxKeyCount,
+		envSubstitution:   true,
+		envLookup:         os.LookupEnv,
+		readAttempts:      1,
+		logger:            slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithProfileSeparator sets the separator between the base file name and the
+// profile name when resolving profile files
+//
+// The default is "-" (app-dev.yaml); WithProfileSeparator(".") resolves
+// app.dev.yaml instead.
+func WithProfileSeparator(separator string) Option {
+	return func(o *options) {
+		o.profileSeparators = []string{separator}
+	}
+}
+
+// WithKeyDelimiter joins nested keys with sep instead of "."
+//
+// Use it when mapping keys contain dots themselves, such as host names:
+// with WithKeyDelimiter("/"), hosts: {app.example.com: {port: 443}} is read
+// with GetInt("hosts/app.example.com/port"). Every key passed to getters,
+// Set, konfig struct tags (including the joins between nested structs),
+// secret keys, key aliases and key group options must use the same
+// delimiter. Profile sections become profiles{sep}{profile}.
+func WithKeyDelimiter(sep string) Option {
+	return func(o *options) {
+		if sep != "" {
+			o.keyDelimiter = sep
+		}
+	}
+}
+
+// WithArrayMergeAppend appends profile list items to base list items instead
+// of replacing the whole list when both define the same key
+func WithArrayMergeAppend() Option {
+	return func(o *options) {
+		o.arrayMerge = arrayMergeAppend
+	}
+}
+
+// WithArrayMergeAppendUnique is like WithArrayMergeAppend but skips profile
+// items equal to one already in the list
+func WithArrayMergeAppendUnique() Option {
+	return func(o *options) {
+		o.arrayMerge = arrayMergeAppendUnique
+	}
+}
+
+// WithMaxFileSize limits how many bytes a single file or LoadURL response
+// may contain
+//
+// The default of 10MB protects against memory exhaustion from oversized or
+// hostile input; 0 disables the limit. Only raise it for trusted files you
+// control, such as generated configuration checked into the repository.
+func WithMaxFileSize(limit int64) Option {
+	return func(o *options) {
+		o.maxFileSize = limit
+	}
+}
+
+// WithMaxKeys limits how many flattened keys a single file may produce
+//
+// The default of 100000 guards against documents with huge fan-out exhausting
+// memory; 0 disables the limit.
+func WithMaxKeys(limit int) Option {
+	return func(o *options) {
+		o.maxKeys = limit
+	}
+}
+
+// WithProfileSections enables single-file profiles for LoadWithProfile
+//
+// When a profile is active, the profiles.{profile} section of each loaded
+// file is merged over that file's top level, and the profiles section itself
+// is removed. Precedence, lowest first: base file, its profile section,
+// profile file, its profile section. ${VAR} substitution runs on each file
+// before merging, so placeholders inside a profile section are resolved too.
+//
+//	server:
+//	  port: 8080
+//	profiles:
+//	  prod:
+//	    server.port: 443
+func WithProfileSections() Option {
+	return func(o *options) {
+		o.profileSections = true
+	}
+}
+
+// WithEnvLookup replaces os.LookupEnv as the source for ${VAR} substitution
+//
+// Tests and sandboxed callers can supply a map-backed lookup instead of
+// mutating the process environment. As with the process environment, a
+// variable that is found but empty falls back to the inline default.
+//
+//	env := map[string]string{"DB_HOST": "db.test"}
+//	cfg, err := konfig.Load(path, konfig.WithEnvLookup(func(name string) (string, bool) {
+//	    value, ok := env[name]
+//	    return value, ok
+//	}))
+func WithEnvLookup(lookup func(string) (string, bool)) Option {
+	return func(o *options) {
+		if lookup != nil {
+			o.envLookup = lookup
+		}
+	}
+}
+
+// WithDefaults supplies fallback values for ${VAR} placeholders that have no
+// inline default, so shared defaults need not be repeated in every file
+//
+// Precedence, highest first: the environment (or WithEnvLookup), the inline
+// ${VAR:default}, then defaults["VAR"]. Placeholders found nowhere become ""
+// and are reported through the WithLogger logger.
+func WithDefaults(defaults map[string]string) Option {
+	return func(o *options) {
+		o.envDefaults = make(map[string]string, len(defaults))
+		for name, value := range defaults {
+			o.envDefaults[name] = value
+		}
+	}
+}
+
+// WithEnvSubstitution turns ${VAR} substitution on or off
+//
+// Substitution is on by default for loaded files and off for FromMap. With it
+// off, placeholders are kept verbatim and ReferencedEnvVars is empty.
+func WithEnvSubstitution(enabled bool) Option {
+	return func(o *options) {
+		o.envSubstitution = enabled
+	}
+}
+
+// WithReadRetry retries reading a configuration file up to attempts times in
+// total when the read fails with a transient error (EIO, EAGAIN, EINTR,
+// ESTALE, ETIMEDOUT), as seen on NFS and FUSE mounts
+//
+// The wait starts at backoff and doubles after every failed attempt. Missing
+// files and permission errors are never retried.
+func WithReadRetry(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		if attempts > 0 {
+			o.readAttempts = attempts
+		}
+		o.readBackoff = backoff
+	}
+}
+
+// WithSecretKeys marks keys whose values are replaced by "[REDACTED]" when the
+// configuration is marshaled, e.g. for logging the effective configuration
+//
+// A key also covers everything below it, so "database" redacts
+// "database.password". Getters and Unmarshal still return the real values.
+func WithSecretKeys(keys ...string) Option {
+	return func(o *options) {
+		o.secretKeys = append(append([]string(nil), o.secretKeys...), keys...)
+	}
+}
+
+// WithLogger sets the logger for warnings emitted while loading, such as the
+// use of a key registered with RegisterKeyAlias; the default is slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// WithWarnUnusedKeys makes LoadInto and LoadIntoWithProfile log every config
+// key that no struct field maps, without failing the load
+//
+// Keys below a mapped field, such as list elements, count as mapped. A nil
+// logger keeps the one from WithLogger.
+func WithWarnUnusedKeys(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.warnUnusedKeys = true
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// WithRequiredTogether makes the LoadInto family return a validation_error
+// unless the keys are either all set or all unset, e.g. a TLS certificate
+// and its private key
+//
+// A key is set when it has a non-empty value or keys below it. The option may
+// be given several times for independent groups.
+func WithRequiredTogether(keys ...string) Option {
+	return func(o *options) {
+		o.keyGroups = append(append([]keyGroup(nil), o.keyGroups...), keyGroup{keys: keys})
+	}
+}
+
+// WithMutuallyExclusive makes the LoadInto family return a validation_error
+// when more than one of the keys is set, e.g. alternative credentials
+//
+// Keys count as set as for WithRequiredTogether; having none set is allowed.
+func WithMutuallyExclusive(keys ...string) Option {
+	return func(o *options) {
+		o.keyGroups = append(append([]keyGroup(nil), o.keyGroups...), keyGroup{keys: keys, exclusive: true})
+	}
+}
+
+// WithImplicitFieldNames makes the LoadInto family map exported fields without
+// a konfig tag to a key named after the field under the parent prefix, so
+// simple structs need no tags
+//
+// The key is the snake_case field name ("MaxConns" -> "max_conns") unless
+// only the lowercased name ("maxconns") is set. Explicit tags always win.
+func WithImplicitFieldNames() Option {
+	return func(o *options) {
+		o.implicitFieldNames = true
+	}
+}
+
+// WithConflictReport records every key that is set by more than one merged
+// layer, such as base and profile files or the overrides file, for auditing
+// which values a layer replaces; read them with Config.Conflicts
+func WithConflictReport() Option {
+	return func(o *options) {
+		o.conflictReport = true
+	}
+}
+
+// WithProvenance records which layer set each key, such as the base file,
+// the profile file, the overrides file or ${VAR} substitution, so that
+// Config.Source can explain an unexpected value; off by default to avoid the
+// per-key bookkeeping
+func WithProvenance() Option {
+	return func(o *options) {
+		o.provenance = true
+	}
+}
+
+// WithKeepEmptySliceItems makes GetStringSlice split scalar values at the
+// same commas and newlines as by default but verbatim, keeping surrounding
+// whitespace and empty items, for lists where position matters ("a,,c"
+// gives ["a" "" "c"])
+func WithKeepEmptySliceItems() Option {
+	return func(o *options) {
+		o.keepEmptySliceItems = true
+	}
+}
+
+// WithYAML11Bools reads unquoted yes/no, y/n and on/off values (in lower,
+// title or upper case) as booleans, as YAML 1.1 parsers did
+//
+// yaml.v3 follows YAML 1.2 and keeps them as strings, so "enabled: no" is the
+// string "no" by default. Quoted values and mapping keys are never coerced.
+func WithYAML11Bools() Option {
+	return func(o *options) {
+		o.yaml11Bools = true
+	}
+}
+
+// WithOverride sets key to value after all files, profiles and ${VAR}
+// substitution have been applied, so it wins over every other source,
+// including the overrides file of LoadWithProfileAndOverrides
+//
+// The option may be given several times; later overrides of the same key
+// win. A map value is flattened below key as Config.Set does. Overrides are
+// reapplied on Reload, and Config.Source reports them as "WithOverride" when
+// WithProvenance is set.
+func WithOverride(key string, value interface{}) Option {
+	return func(o *options) {
+		o.overrides = append(append([]override(nil), o.overrides...), override{key: key, value: value})
+	}
+}
+
+// WithRequireProfileFile makes LoadWithProfile and the other profile loaders
+// return a file_not_found error when the profile file is missing, instead of
+// silently using the base file alone
+//
+// With no arguments every profile needs a file; otherwise only the listed
+// ones do, so WithRequireProfileFile("prod") fails fast on a misnamed
+// production override while dev may still run from the base file. The file
+// is required even with WithProfileSections; an empty profile loads the base
+// file alone as before.
+func WithRequireProfileFile(profiles ...string) Option {
+	return func(o *options) {
+		o.requireProfileFile = true
+		o.requiredProfiles = append(append([]string(nil), o.requiredProfiles...), profiles...)
+	}
+}
+
+// WithStrictSchema makes LoadWithSchema reject files that declare no schema
+// version instead of accepting them as unversioned
+func WithStrictSchema() Option {
+	return func(o *options) {
+		o.strictSchema = true
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -88,6 +89,39 @@ func TestProductionRobustness_FileSystemEdgeCases(t *testing.T) {
 		assert.Empty(t, cfg.Keys())
 	})
 
+	t.Run("blank_documents", func(t *testing.T) {
+		contents := map[string]string{
+			"empty":        "",
+			"whitespace":   "  \n\t\n   \n",
+			"comment_only": "# nothing configured yet\n  # indented comment\n",
+			"null_doc":     "---\n",
+		}
+
+		for name, content := range contents {
+			t.Run(name, func(t *testing.T) {
+				tempDir := t.TempDir()
+				configPath := filepath.Join(tempDir, "app.yaml")
+				require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app-prod.yaml"), []byte(content), 0644))
+
+				cfg, err := Load(configPath)
+				require.NoError(t, err)
+				assert.Empty(t, cfg.Keys())
+
+				cfg, err = LoadWithProfile(configPath, "prod")
+				require.NoError(t, err)
+				assert.Empty(t, cfg.Keys())
+
+				doc, err := LoadNode(configPath)
+				require.NoError(t, err)
+				require.NoError(t, doc.Set("server.port", 8080))
+				value, ok := doc.Get("server.port")
+				assert.True(t, ok)
+				assert.Equal(t, 8080, value)
+			})
+		}
+	})
+
 	t.Run("readonly_file", func(t *testing.T) {
 		if os.Getuid() == 0 {
 			t.Skip("Skipping readonly test as root user")
@@ -128,6 +162,64 @@ func TestProductionRobustness_FileSystemEdgeCases(t *testing.T) {
 	})
 }
 
+func TestProductionRobustness_TransientReadRetry(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "mounted.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 8080"), 0644))
+
+	// failingReader fails the first n reads with err, then reads normally
+	failingReader := func(n int, err error) (func(string) ([]byte, error), *int) {
+		calls := 0
+		return func(name string) ([]byte, error) {
+			calls++
+			if calls <= n {
+				return nil, &os.PathError{Op: "read", Path: name, Err: err}
+			}
+			return os.ReadFile(name)
+		}, &calls
+	}
+
+	t.Cleanup(func() { readFile = os.ReadFile })
+
+	t.Run("transient_error_recovers", func(t *testing.T) {
+		reader, calls := failingReader(2, syscall.ESTALE)
+		readFile = reader
+
+		cfg, err := Load(configPath, WithReadRetry(3, time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, 8080, cfg.GetInt("server.port"))
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("attempts_exhausted", func(t *testing.T) {
+		reader, calls := failingReader(5, syscall.EIO)
+		readFile = reader
+
+		_, err := Load(configPath, WithReadRetry(3, time.Millisecond))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parse_error")
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("no_retry_by_default", func(t *testing.T) {
+		reader, calls := failingReader(1, syscall.EIO)
+		readFile = reader
+
+		_, err := Load(configPath)
+		require.Error(t, err)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("permanent_error_not_retried", func(t *testing.T) {
+		reader, calls := failingReader(5, syscall.ENOENT)
+		readFile = reader
+
+		_, err := Load(configPath, WithReadRetry(3, time.Millisecond))
+		require.Error(t, err)
+		assert.Equal(t, 1, *calls)
+	})
+}
+
 func TestProductionRobustness_LargeConfigurations(t *testing.T) {
 	t.Run("large_config_file", func(t *testing.T) {
 		tempDir := t.TempDir()
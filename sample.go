@@ -0,0 +1,163 @@
+package konfig
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretPlaceholder is written by GenerateSample for secret fields without an
+// env tag
+const secretPlaceholder = "CHANGE_ME"
+
+// GenerateSample renders a commented YAML skeleton for a config struct, with
+// every mapped key set to its default tag value
+//
+// Keys follow the same rules as LoadInto: konfig tags are relative to the
+// parent struct, untagged nested structs use their lowercased field name and
+// untagged scalar fields are skipped. Each key carries a comment naming the
+// Go type and any env tag or defaultFunc. Keys without a default, and those
+// whose default reads a ${file:PATH}, are left empty, so loading the sample
+// unchanged yields the struct defaults. Fields
+// tagged secret:"true" get a "${ENV}" placeholder when they have an env tag
+// and CHANGE_ME otherwise.
+//
+// Example:
+//
+//	sample, err := konfig.GenerateSample(AppConfig{})
+//	os.WriteFile("config.example.yaml", sample, 0644)
+func GenerateSample(v interface{}) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    "sample",
+			Message: fmt.Sprintf("GenerateSample needs a struct, got %T", v),
+		}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	addSampleFields(root, t)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    "sample",
+			Message: "failed to encode sample configuration",
+			Cause:   err,
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// addSampleFields adds the keys of struct type t to the mapping node parent
+func addSampleFields(parent *yaml.Node, t reflect.Type) {
+	for _, field := range structFields(t) {
+		structField := t.Field(field.index)
+		if !structField.IsExported() {
+			continue
+		}
+
+		key := field.key
+		if key == "" {
+			if !field.nested {
+				continue
+			}
+			key = strings.ToLower(field.name)
+		}
+
+		// Dotted tags such as "server.port" become nested mappings
+		segments := strings.Split(key, ".")
+		mapping := parent
+		for _, segment := range segments[:len(segments)-1] {
+			mapping = sampleChild(mapping, segment)
+		}
+		last := segments[len(segments)-1]
+
+		if field.nested {
+			addSampleFields(sampleChild(mapping, last), structField.Type)
+			continue
+		}
+
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: last, HeadComment: sampleComment(structField.Type, field.tags)}
+		mapping.Content = append(mapping.Content, keyNode, sampleValue(structField, field.tags))
+	}
+}
+
+// sampleChild returns the mapping stored under key in parent, adding it if needed
+func sampleChild(parent *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key && parent.Content[i+1].Kind == yaml.MappingNode {
+			return parent.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child
+}
+
+// sampleComment describes a field for the line above its key
+func sampleComment(fieldType reflect.Type, tags fieldTags) string {
+	parts := []string{fieldType.String()}
+	if tags.required {
+		parts = append(parts, "required")
+	}
+	if tags.env != "" {
+		parts = append(parts, "env "+tags.env)
+	}
+	if tags.defaultFunc != "" && tags.defaultValue == "" {
+		parts = append(parts, "default from "+tags.defaultFunc)
+	}
+	if fileRefRegex.MatchString(tags.defaultValue) {
+		parts = append(parts, "default "+tags.defaultValue)
+	}
+	if tags.format != "" {
+		parts = append(parts, "format "+tags.format)
+	}
+	if tags.layout != "" {
+		parts = append(parts, "layout "+tags.layout)
+	}
+	if tags.unit != "" {
+		parts = append(parts, "unit "+tags.unit)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sampleValue renders the default of a field, an empty value if it has none
+func sampleValue(field reflect.StructField, tags fieldTags) *yaml.Node {
+	value := tags.defaultValue
+	if fileRefRegex.MatchString(value) {
+		// Written as is, ${file:PATH} would be read back as ${VAR:default}
+		// substitution of a variable named "file"; the comment shows it and
+		// the empty value lets the default tag read the file
+		value = ""
+	}
+	if field.Tag.Get("secret") == "true" {
+		value = secretPlaceholder
+		if tags.env != "" {
+			value = "${" + tags.env + "}"
+		}
+	}
+
+	if value == "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	if field.Type.Kind() == reflect.String {
+		// Keep string defaults such as "8080" or "yes" strings when reloaded
+		node.Tag = "!!str"
+	}
+	return node
+}
@@ -1,6 +1,7 @@
 package konfig
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -76,6 +77,40 @@ func TestSecurity_FileSizeLimit(t *testing.T) {
 	}
 }
 
+func TestSecurity_MaxFileSizeOption(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	smallPath := filepath.Join(tmpDir, "small.yaml")
+	if err := os.WriteFile(smallPath, []byte("server:\n  port: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A lower limit rejects files the default would accept
+	_, err := Load(smallPath, WithMaxFileSize(8))
+	if err == nil || !strings.Contains(err.Error(), "file too large") {
+		t.Errorf("Expected file size error with 8 byte limit, got: %v", err)
+	}
+
+	// 0 disables the limit for trusted files over the 10MB default
+	largePath := filepath.Join(tmpDir, "large.yaml")
+	largeData := "flags: " + strings.Repeat("x", maxFileSize+1) + "\n"
+	if err := os.WriteFile(largePath, []byte(largeData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(largePath); err == nil {
+		t.Error("Expected default limit to reject large file")
+	}
+
+	cfg, err := Load(largePath, WithMaxFileSize(0))
+	if err != nil {
+		t.Fatalf("Expected unlimited size to load large file, got: %v", err)
+	}
+	if got := len(cfg.GetString("flags")); got != maxFileSize+1 {
+		t.Errorf("Expected %d byte value, got %d", maxFileSize+1, got)
+	}
+}
+
 func TestSecurity_YAMLComplexityLimit(t *testing.T) {
 	// Create deeply nested YAML that exceeds complexity limits
 	deepYAML := "root:\n"
@@ -106,6 +141,37 @@ func TestSecurity_YAMLComplexityLimit(t *testing.T) {
 	}
 }
 
+func TestSecurity_KeyCountLimit(t *testing.T) {
+	// Wide fan-out: 50 sections x 50 keys = 2500 flattened keys
+	var builder strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&builder, "section%d:\n", i)
+		for j := 0; j < 50; j++ {
+			fmt.Fprintf(&builder, "  key%d: value\n", j)
+		}
+	}
+
+	configPath := filepath.Join(t.TempDir(), "wide.yaml")
+	if err := os.WriteFile(configPath, []byte(builder.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(configPath, WithMaxKeys(1000))
+	if err == nil {
+		t.Error("Expected configuration exceeding the key limit to be rejected")
+	} else if !strings.Contains(err.Error(), "validation_error") || !strings.Contains(err.Error(), "key count exceeds maximum of 1000") {
+		t.Errorf("Expected key count validation error, got: %v", err)
+	}
+
+	// The default limit and an explicit 0 (unlimited) both accept it
+	if _, err := Load(configPath); err != nil {
+		t.Errorf("Expected default key limit to accept 2500 keys, got: %v", err)
+	}
+	if _, err := Load(configPath, WithMaxKeys(0)); err != nil {
+		t.Errorf("Expected unlimited key count to accept configuration, got: %v", err)
+	}
+}
+
 func TestSecurity_SymlinkHandling(t *testing.T) {
 	// Create a test file
 	tmpFile, err := os.CreateTemp("", "target-*.yaml")
@@ -0,0 +1,156 @@
+package konfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// defaultURLTimeout bounds a LoadURL request when the context has no deadline
+const defaultURLTimeout = 30 * time.Second
+
+// LoadURL loads configuration from an HTTP(S) endpoint such as a config server
+//
+// The response body is limited to the same size as local files (10MB unless
+// changed with WithMaxFileSize) and goes through the normal parsing,
+// flattening and substitution pipeline. The format is taken from the
+// Content-Type header, falling back to the URL's extension; YAML and JSON are
+// accepted. Non-2xx responses return a file_not_found (404) or parse_error
+// ConfigError.
+//
+// Example:
+//
+//	cfg, err := konfig.LoadURL(ctx, "https://config.internal/app.yaml")
+func LoadURL(ctx context.Context, rawURL string, opts ...Option) (Config, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    rawURL,
+			Message: "URL must be an absolute http or https URL",
+			Cause:   err,
+		}
+	}
+
+	o := applyOptions(opts)
+	cfg, err := withOverrides(func() (*config, error) { return loadFromURL(ctx, rawURL, o) }, o)()
+	if err != nil {
+		return nil, err
+	}
+	cfg.source = withOverrides(func() (*config, error) { return loadFromURL(context.Background(), rawURL, o) }, o)
+
+	return cfg, nil
+}
+
+func loadFromURL(ctx context.Context, rawURL string, o options) (*config, error) {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultURLTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    rawURL,
+			Message: "failed to create request",
+			Cause:   err,
+		}
+	}
+	req.Header.Set("Accept", "application/yaml, application/json;q=0.9, */*;q=0.1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    rawURL,
+			Message: "failed to fetch configuration",
+			Cause:   err,
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errType := "parse_error"
+		if resp.StatusCode == http.StatusNotFound {
+			errType = "file_not_found"
+		}
+		return nil, &ConfigError{
+			Type:    errType,
+			Path:    rawURL,
+			Message: fmt.Sprintf("unexpected HTTP status %s", resp.Status),
+		}
+	}
+
+	if !isSupportedRemoteFormat(resp.Header.Get("Content-Type"), req.URL.Path) {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    rawURL,
+			Message: fmt.Sprintf("unsupported configuration format (Content-Type %q)", resp.Header.Get("Content-Type")),
+		}
+	}
+
+	// Security: Enforce file size limit on the response body
+	var body io.Reader = resp.Body
+	if o.maxFileSize > 0 {
+		body = io.LimitReader(resp.Body, o.maxFileSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    rawURL,
+			Message: "failed to read response body",
+			Cause:   err,
+		}
+	}
+	if o.maxFileSize > 0 && int64(len(data)) > o.maxFileSize {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    rawURL,
+			Message: "failed to read response body",
+			Cause:   fmt.Errorf("file too large: more than %d bytes", o.maxFileSize),
+		}
+	}
+
+	configMap, err := parseYAMLBytes(data, o)
+	if err != nil {
+		return nil, &ConfigError{
+			Type:    "parse_error",
+			Path:    rawURL,
+			Message: "failed to parse configuration",
+			Cause:   err,
+		}
+	}
+
+	return buildConfig(configMap, rawURL, o)
+}
+
+// isSupportedRemoteFormat reports whether a response is YAML or JSON, judged
+// by Content-Type first and the URL path extension second
+func isSupportedRemoteFormat(contentType, urlPath string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case mediaType == "application/json",
+			strings.HasSuffix(mediaType, "+json"),
+			strings.HasSuffix(mediaType, "yaml"):
+			return true
+		case mediaType != "text/plain" && mediaType != "application/octet-stream":
+			return false
+		}
+	}
+
+	// Generic or missing Content-Type: decide by extension
+	switch strings.ToLower(path.Ext(urlPath)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
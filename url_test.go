@@ -0,0 +1,92 @@
+package konfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("server:\n  port: 8080\n  host: ${KONFIG_URL_HOST:remote}\n"))
+	})
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"server": {"port": 9090}}`))
+	})
+	mux.HandleFunc("/plain.yml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("name: plain\n"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	})
+	mux.HandleFunc("/broken.yaml", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/huge.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte(strings.Repeat("key: value\n", 1024*1024)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("yaml by content type", func(t *testing.T) {
+		cfg, err := LoadURL(ctx, server.URL+"/app.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, 8080, cfg.GetInt("server.port"))
+		assert.Equal(t, "remote", cfg.GetString("server.host"))
+	})
+
+	t.Run("json by content type", func(t *testing.T) {
+		cfg, err := LoadURL(ctx, server.URL+"/config")
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.GetInt("server.port"))
+	})
+
+	t.Run("generic content type falls back to extension", func(t *testing.T) {
+		cfg, err := LoadURL(ctx, server.URL+"/plain.yml")
+		require.NoError(t, err)
+		assert.Equal(t, "plain", cfg.GetString("name"))
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := LoadURL(ctx, server.URL+"/page")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported configuration format")
+	})
+
+	t.Run("non-2xx status", func(t *testing.T) {
+		_, err := LoadURL(ctx, server.URL+"/broken.yaml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "500")
+
+		_, err = LoadURL(ctx, server.URL+"/missing.yaml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file_not_found")
+	})
+
+	t.Run("body size limit", func(t *testing.T) {
+		_, err := LoadURL(ctx, server.URL+"/huge.yaml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file too large")
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		_, err := LoadURL(ctx, "file:///etc/passwd")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "validation_error")
+	})
+}
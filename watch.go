@@ -0,0 +1,201 @@
+package konfig
+
+import (
+	"context"
+	"math/rand/v2"
+	"reflect"
+	"time"
+)
+
+// watchErrorBuffer is the capacity of the channel returned by Watch
+const watchErrorBuffer = 8
+
+// Watch polls the configuration sources and applies changes until ctx is done
+//
+// Every interval (plus up to 10% random jitter, so many instances polling a
+// shared config server spread out) the sources are re-read. A change is
+// applied only once it has been stable for the debounce window, so an editor
+// writing a file in several steps causes a single update. Applying a change
+// works like Reload: values are swapped atomically and bound structs are
+// re-populated.
+//
+// Load failures, such as invalid YAML saved mid-edit, never stop the watcher
+// and never replace the last good values. They are sent on the returned
+// channel, which is buffered; errors are dropped while it is full and a
+// repeated identical error is reported once. The channel is closed when the
+// watcher stops.
+//
+// Values changed with Set are kept until the sources themselves change.
+// Close stops all watchers of the configuration.
+//
+// Example:
+//
+//	errs, err := cfg.Watch(ctx, 2*time.Second, 500*time.Millisecond)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	go func() {
+//	    for err := range errs {
+//	        log.Printf("config reload failed: %v", err)
+//	    }
+//	}()
+func (c *config) Watch(ctx context.Context, interval, debounce time.Duration) (<-chan error, error) {
+	if c.source == nil {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    "config",
+			Message: "configuration has no reloadable source",
+		}
+	}
+	if interval <= 0 {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    "config",
+			Message: "watch interval must be positive",
+		}
+	}
+
+	// Compare polls against what the sources hold now, not against the
+	// current values, which may include Set or WithOverride changes that a
+	// poll of unchanged sources must not undo
+	last := c.snapshot()
+	if current, err := c.source(); err == nil {
+		last = current.snapshot()
+	}
+
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.closed {
+		return nil, &ConfigError{
+			Type:    "validation_error",
+			Path:    "config",
+			Message: "configuration is closed",
+		}
+	}
+
+	errs := make(chan error, watchErrorBuffer)
+	w := &watcher{cfg: c, errs: errs, last: last}
+	ctx, cancel := context.WithCancel(ctx)
+	if c.watchers == nil {
+		c.watchers = make(map[*watcher]context.CancelFunc)
+	}
+	c.watchers[w] = cancel
+	c.watchersDone.Add(1)
+	go func() {
+		defer c.watchersDone.Done()
+		defer c.forgetWatcher(w)
+		w.run(ctx, interval, debounce)
+	}()
+
+	return errs, nil
+}
+
+// Close stops the watchers and waits for their goroutines to exit
+func (c *config) Close() error {
+	c.watchMu.Lock()
+	c.closed = true
+	for _, cancel := range c.watchers {
+		cancel()
+	}
+	c.watchMu.Unlock()
+
+	c.watchersDone.Wait()
+	return nil
+}
+
+// forgetWatcher releases the context of a watcher that has stopped
+func (c *config) forgetWatcher(w *watcher) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	if cancel, ok := c.watchers[w]; ok {
+		cancel()
+		delete(c.watchers, w)
+	}
+}
+
+// watcher is the state of one Watch loop
+type watcher struct {
+	cfg  *config
+	errs chan error
+
+	// last holds the values most recently read from the sources
+	last map[string]interface{}
+
+	// lastErr is the last reported error message, to report repeats once
+	lastErr string
+}
+
+func (w *watcher) run(ctx context.Context, interval, debounce time.Duration) {
+	defer close(w.errs)
+
+	for {
+		jitter := time.Duration(rand.Int64N(int64(interval)/10 + 1))
+		if !sleepContext(ctx, interval+jitter) {
+			return
+		}
+
+		fresh, err := w.cfg.source()
+		if err != nil {
+			w.report(err)
+			continue
+		}
+		if reflect.DeepEqual(fresh.snapshot(), w.last) {
+			w.lastErr = ""
+			continue
+		}
+
+		// Wait for the change to settle before applying it
+		if debounce > 0 {
+			if !sleepContext(ctx, debounce) {
+				return
+			}
+			settled, err := w.cfg.source()
+			if err != nil {
+				w.report(err)
+				continue
+			}
+			if !reflect.DeepEqual(settled.snapshot(), fresh.snapshot()) {
+				continue
+			}
+			fresh = settled
+		}
+
+		w.cfg.bindMu.Lock()
+		err = w.cfg.apply(fresh)
+		w.cfg.bindMu.Unlock()
+		if err != nil {
+			w.report(err)
+			continue
+		}
+
+		w.last = fresh.snapshot()
+		w.lastErr = ""
+	}
+}
+
+// report delivers err without blocking, skipping repeats of the last error
+func (w *watcher) report(err error) {
+	if err.Error() == w.lastErr {
+		return
+	}
+	w.lastErr = err.Error()
+
+	select {
+	case w.errs <- err:
+	default:
+	}
+}
+
+// sleepContext waits for d and reports whether ctx is still active
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,146 @@
+package konfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 8080\n"), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	type ServerConfig struct {
+		Port int `konfig:"server.port"`
+	}
+	var bound ServerConfig
+	require.NoError(t, cfg.BindStruct(&bound))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs, err := cfg.Watch(ctx, 10*time.Millisecond, 20*time.Millisecond)
+	require.NoError(t, err)
+
+	// A valid edit is applied
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 9090\n"), 0644))
+	require.Eventually(t, func() bool { return cfg.GetInt("server.port") == 9090 }, 2*time.Second, 5*time.Millisecond)
+
+	// A broken edit is reported and the last good values stay active
+	require.NoError(t, os.WriteFile(configPath, []byte("server: [unclosed"), 0644))
+	select {
+	case err := <-errs:
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, "parse_error", configErr.Type)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a reload error")
+	}
+	assert.Equal(t, 9090, cfg.GetInt("server.port"))
+	assert.Equal(t, 9090, bound.Port)
+
+	// Fixing the file resumes updates
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 7070\n"), 0644))
+	require.Eventually(t, func() bool { return cfg.GetInt("server.port") == 7070 }, 2*time.Second, 5*time.Millisecond)
+
+	// The channel is closed once the watcher stops
+	cancel()
+	require.Eventually(t, func() bool {
+		for {
+			select {
+			case _, ok := <-errs:
+				if !ok {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestWatch_KeepsSetValues(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 8080\n"), 0644))
+
+	cfg, err := Load(configPath, WithOverride("server.host", "override"))
+	require.NoError(t, err)
+	cfg.Set("server.port", 9999)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = cfg.Watch(ctx, 5*time.Millisecond, 0)
+	require.NoError(t, err)
+
+	// Many polls of the unchanged file must not undo Set
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 9999, cfg.GetInt("server.port"))
+	assert.Equal(t, "override", cfg.GetString("server.host"))
+
+	// A change to the file itself is applied
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 7070\n"), 0644))
+	require.Eventually(t, func() bool { return cfg.GetInt("server.port") == 7070 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, "override", cfg.GetString("server.host"))
+}
+
+func TestWatch_Validation(t *testing.T) {
+	_, err := newConfig(map[string]interface{}{}).Watch(context.Background(), time.Second, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no reloadable source")
+
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("a: 1\n"), 0644))
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	_, err = cfg.Watch(context.Background(), 0, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "interval must be positive")
+}
+
+func TestWatchClose(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 8080\n"), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	before := runtime.NumGoroutine()
+	var channels []<-chan error
+	for i := 0; i < 3; i++ {
+		errs, err := cfg.Watch(context.Background(), 10*time.Millisecond, 0)
+		require.NoError(t, err)
+		channels = append(channels, errs)
+	}
+	assert.GreaterOrEqual(t, runtime.NumGoroutine(), before+3)
+
+	require.NoError(t, cfg.Close())
+	require.NoError(t, cfg.Close(), "Close is idempotent")
+
+	// Close waits for the watchers, so their channels are already closed
+	for _, errs := range channels {
+		_, ok := <-errs
+		assert.False(t, ok)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "watch goroutines have exited")
+
+	// The last snapshot stays readable, but no new watchers start
+	require.NoError(t, os.WriteFile(configPath, []byte("server:\n  port: 9090\n"), 0644))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 8080, cfg.GetInt("server.port"))
+
+	_, err = cfg.Watch(context.Background(), 10*time.Millisecond, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration is closed")
+
+	// Configurations without watchers close cleanly too
+	require.NoError(t, FromMap(map[string]interface{}{"a": 1}).Close())
+}
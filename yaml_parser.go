@@ -1,11 +1,17 @@
 package konfig
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,10 +19,86 @@ import (
 const (
 	maxFileSize     = 10 * 1024 * 1024 // 10MB max file size
 	maxNestingDepth = 32               // Maximum YAML nesting depth
+	maxKeyCount     = 100000           // Default maximum number of flattened keys
 )
 
 // parseYAMLFile reads and parses a YAML file into a map with security validations
-func parseYAMLFile(filePath string) (map[string]interface{}, error) {
+func parseYAMLFile(filePath string, o options) (map[string]interface{}, error) {
+	data, err := readConfigFile(filePath, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseYAMLBytes(data, o)
+}
+
+// parseYAMLBytes parses YAML content into a map with complexity validation
+func parseYAMLBytes(data []byte, o options) (map[string]interface{}, error) {
+	// Whitespace-only content is an empty document; YAML itself rejects tabs
+	if isBlankDocument(data) {
+		return map[string]interface{}{}, nil
+	}
+
+	var result map[string]interface{}
+	if o.yaml11Bools {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		coerceYAML11Bools(&doc)
+		if err := doc.Decode(&result); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	} else if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	// Security: Validate YAML complexity
+	if err := validateYAMLComplexity(result, 0); err != nil {
+		return nil, fmt.Errorf("YAML too complex: %w", err)
+	}
+
+	return result, nil
+}
+
+// yaml11Bools maps the YAML 1.1 boolean spellings that YAML 1.2, and so
+// yaml.v3, reads as strings
+var yaml11Bools = map[string]string{
+	"y": "true", "Y": "true", "yes": "true", "Yes": "true", "YES": "true",
+	"on": "true", "On": "true", "ON": "true",
+	"n": "false", "N": "false", "no": "false", "No": "false", "NO": "false",
+	"off": "false", "Off": "false", "OFF": "false",
+}
+
+// coerceYAML11Bools retags plain (unquoted) scalar values spelled as YAML 1.1
+// booleans as booleans; mapping keys such as "on" and quoted values are kept
+func coerceYAML11Bools(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			coerceYAML11Bools(child)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			coerceYAML11Bools(node.Content[i])
+		}
+	case yaml.ScalarNode:
+		if value, ok := yaml11Bools[node.Value]; ok && node.Style == 0 && node.Tag == "!!str" {
+			node.Tag, node.Value = "!!bool", value
+		}
+	}
+}
+
+// isBlankDocument reports whether data holds nothing but whitespace
+func isBlankDocument(data []byte) bool {
+	return len(bytes.TrimSpace(data)) == 0
+}
+
+// readFile is the file reader used by readConfigFile; replaced in tests
+var readFile = os.ReadFile
+
+// readConfigFile reads a configuration file after path and size validations
+func readConfigFile(filePath string, o options) ([]byte, error) {
 	// Security: Prevent path traversal attacks before cleaning
 	if strings.Contains(filePath, "..") {
 		return nil, fmt.Errorf("path traversal not allowed: %s", filePath)
@@ -32,26 +114,38 @@ func parseYAMLFile(filePath string) (map[string]interface{}, error) {
 	}
 
 	// Security: Enforce file size limit
-	if fileInfo.Size() > maxFileSize {
-		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", fileInfo.Size(), maxFileSize)
+	if o.maxFileSize > 0 && fileInfo.Size() > o.maxFileSize {
+		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", fileInfo.Size(), o.maxFileSize)
+	}
+
+	data, err := readFile(cleanPath)
+
+	// Retry transient failures (e.g. on network filesystems) with doubling backoff
+	backoff := o.readBackoff
+	for attempt := 1; err != nil && attempt < o.readAttempts && isTransientReadError(err); attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		data, err = readFile(cleanPath)
 	}
 
-	data, err := os.ReadFile(cleanPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var result map[string]interface{}
-	if err := yaml.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
-	}
+	return data, nil
+}
 
-	// Security: Validate YAML complexity
-	if err := validateYAMLComplexity(result, 0); err != nil {
-		return nil, fmt.Errorf("YAML too complex: %w", err)
+// isTransientReadError reports whether a read failure may succeed on retry
+func isTransientReadError(err error) bool {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return false
 	}
-
-	return result, nil
+	for _, errno := range []syscall.Errno{syscall.EIO, syscall.EAGAIN, syscall.EINTR, syscall.ESTALE, syscall.ETIMEDOUT} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
 }
 
 // validateYAMLComplexity prevents deeply nested YAML from causing stack overflow
@@ -67,6 +161,12 @@ func validateYAMLComplexity(data interface{}, depth int) error {
 				return err
 			}
 		}
+	case map[interface{}]interface{}:
+		for _, value := range v {
+			if err := validateYAMLComplexity(value, depth+1); err != nil {
+				return err
+			}
+		}
 	case []interface{}:
 		for _, item := range v {
 			if err := validateYAMLComplexity(item, depth+1); err != nil {
@@ -78,72 +178,188 @@ func validateYAMLComplexity(data interface{}, depth int) error {
 	return nil
 }
 
-// flattenMap converts nested maps into dot-notation keys
-func flattenMap(m map[string]interface{}, prefix string) map[string]interface{} {
+// flattenMap converts nested maps into keys joined by sep, e.g. dot notation
+func flattenMap(m map[string]interface{}, prefix, sep string) map[string]interface{} {
 	result := make(map[string]interface{})
+	_ = flattenInto(result, m, prefix, sep, 0)
+	return result
+}
+
+// flattenMapLimited is flattenMap with a cap on the number of resulting keys;
+// a limit of 0 disables the check
+func flattenMapLimited(m map[string]interface{}, prefix, sep string, limit int) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if err := flattenInto(result, m, prefix, sep, limit); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
+// flattenInto writes the flattened entries of m into result
+func flattenInto(result, m map[string]interface{}, prefix, sep string, limit int) error {
 	for key, value := range m {
 		fullKey := key
 		if prefix != "" {
-			fullKey = prefix + "." + key
+			fullKey = prefix + sep + key
 		}
 
 		switch v := value.(type) {
 		case map[string]interface{}:
 			// Recursively flatten nested maps
-			nested := flattenMap(v, fullKey)
-			for nestedKey, nestedValue := range nested {
-				result[nestedKey] = nestedValue
+			if err := flattenInto(result, v, fullKey, sep, limit); err != nil {
+				return err
+			}
+		case map[interface{}]interface{}:
+			// yaml.v3 produces these when a mapping has non-string keys (e.g. 2024:)
+			if err := flattenInto(result, stringifyMapKeys(v), fullKey, sep, limit); err != nil {
+				return err
 			}
 		default:
 			result[fullKey] = value
+
+			// Security: Bound the number of keys a document can expand into
+			if limit > 0 && len(result) > limit {
+				return fmt.Errorf("key count exceeds maximum of %d", limit)
+			}
+		}
+	}
+
+	return nil
+}
+
+// unflattenMap is the inverse of flattenMap, nesting keys joined by sep into
+// map[string]interface{} values
+//
+// A key that collides with a value at one of its parent paths (e.g. both
+// "ports" and "ports.1") is kept as a joined key in the deepest map that can
+// hold it, so no value is dropped.
+func unflattenMap(flat map[string]interface{}, sep string) map[string]interface{} {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	// Sorting places every key before the keys below it
+	sort.Strings(keys)
+
+	root := make(map[string]interface{})
+	for _, key := range keys {
+		current := root
+		segments := strings.Split(key, sep)
+		for i, segment := range segments[:len(segments)-1] {
+			next, exists := current[segment]
+			if !exists {
+				child := make(map[string]interface{})
+				current[segment] = child
+				current = child
+				continue
+			}
+			child, isMap := next.(map[string]interface{})
+			if !isMap {
+				segments = append(segments[:i], strings.Join(segments[i:], sep))
+				break
+			}
+			current = child
 		}
+		current[segments[len(segments)-1]] = flat[key]
 	}
 
+	return root
+}
+
+// stringifyMapKeys converts non-string mapping keys to their string form
+func stringifyMapKeys(m map[interface{}]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		result[fmt.Sprintf("%v", key)] = value
+	}
 	return result
 }
 
+// envVarRegex matches ${VAR} or ${VAR:default}
+var envVarRegex = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
+
 // processEnvSubstitutions processes ${VAR} and ${VAR:default} substitutions
-func processEnvSubstitutions(m map[string]interface{}) (map[string]interface{}, error) {
+// using o.envLookup, then the inline default, then o.envDefaults, adding the
+// name of every variable read to referenced. Placeholders that resolve empty
+// without any default are logged as warnings.
+func processEnvSubstitutions(m map[string]interface{}, o options, referenced map[string]struct{}) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
-	// Regular expression to match ${VAR} or ${VAR:default}
-	envVarRegex := regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
-
 	for key, value := range m {
-		strValue := fmt.Sprintf("%v", value)
+		result[key] = substituteValue(key, value, o, referenced)
+	}
 
-		// Process all environment variable substitutions in the string
-		processedValue := envVarRegex.ReplaceAllStringFunc(strValue, func(match string) string {
-			matches := envVarRegex.FindStringSubmatch(match)
-			if len(matches) < 2 {
-				return match // Should not happen, but safety first
-			}
+	return result, nil
+}
 
-			envVar := matches[1]
-			defaultVal := ""
-			if len(matches) > 2 {
-				defaultVal = matches[2]
-			}
+// substituteValue substitutes placeholders in value; list elements and the
+// values of mappings inside lists are substituted one by one so that
+// untouched elements keep their YAML type
+func substituteValue(key string, value interface{}, o options, referenced map[string]struct{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = substituteValue(key, item, o, referenced)
+		}
+		return items
+	case map[string]interface{}:
+		entries := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			entries[k] = substituteValue(key+o.keyDelimiter+k, item, o, referenced)
+		}
+		return entries
+	case map[interface{}]interface{}:
+		entries := make(map[interface{}]interface{}, len(v))
+		for k, item := range v {
+			entries[k] = substituteValue(fmt.Sprintf("%s%s%v", key, o.keyDelimiter, k), item, o, referenced)
+		}
+		return entries
+	}
 
-			// Get environment variable value
-			if envValue := os.Getenv(envVar); envValue != "" {
-				return envValue
-			}
+	strValue := fmt.Sprintf("%v", value)
+
+	// Process all environment variable substitutions in the string
+	processedValue := envVarRegex.ReplaceAllStringFunc(strValue, func(match string) string {
+		matches := envVarRegex.FindStringSubmatchIndex(match)
+		if len(matches) < 4 {
+			return match // Should not happen, but safety first
+		}
+
+		envVar := match[matches[2]:matches[3]]
+		referenced[envVar] = struct{}{}
+
+		// Get environment variable value
+		envValue, found := o.envLookup(envVar)
+		if found && envValue != "" {
+			return envValue
+		}
 
-			// Use default value if environment variable is not set
+		// Use the inline default, even if empty, when one is given
+		if len(matches) > 5 && matches[4] >= 0 {
+			return match[matches[4]:matches[5]]
+		}
+
+		// Fall back to the WithDefaults map
+		if defaultVal, found := o.envDefaults[envVar]; found {
 			return defaultVal
-		})
+		}
 
-		// Convert back to appropriate type if possible
-		if processedValue != strValue {
-			// String was modified, keep as string
-			result[key] = processedValue
-		} else {
-			// String was not modified, keep original type
-			result[key] = value
+		message := "environment variable not set and no default given"
+		if found {
+			message = "environment variable empty and no default given"
 		}
-	}
+		o.logger.Warn(message,
+			"variable", envVar,
+			"key", key)
+		return ""
+	})
 
-	return result, nil
+	// Convert back to appropriate type if possible
+	if processedValue != strValue {
+		// String was modified, keep as string
+		return processedValue
+	}
+	// String was not modified, keep original type
+	return value
 }